@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -40,6 +42,33 @@ func ConfigWithDefault(key, defaultValue string) string {
 	return value
 }
 
+// Mengambil nilai durasi (contoh: "15m", "72h"), gunakan defaultValue jika kosong atau tidak valid
+func DurationWithDefault(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// Mengambil nilai boolean (true/false/1/0), gunakan defaultValue jika kosong atau tidak valid
+func BoolWithDefault(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// Mengambil nilai bilangan bulat positif, gunakan defaultValue jika kosong, tidak valid atau <= 0
+func IntWithDefault(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
 // Apakah aplikasi berjalan di lingkungan produksi?
 func IsProduction() bool {
 	return os.Getenv("APP_ENV") == "production"
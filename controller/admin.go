@@ -0,0 +1,457 @@
+package controller
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"jalurku/database"
+	"jalurku/model"
+	"jalurku/token"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ============================================
+// ADMIN HANDLERS
+// ============================================
+
+// Mencabut semua token milik pengguna (misalnya akun disusupi atau role berubah)
+func RevokeUserTokens(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid user ID",
+			"data":    nil,
+		})
+	}
+
+	db := database.DB
+	var user model.User
+	if err := db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "User not found",
+				"data":    nil,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Database error",
+			"data":    err.Error(),
+		})
+	}
+
+	if msg := manageBlocked(c, &user, ""); msg != "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": msg,
+			"data":    nil,
+		})
+	}
+
+	if err := token.RevokeUser(user.ID.String()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't revoke tokens",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "All tokens of the user have been revoked",
+		"data":    nil,
+	})
+}
+
+// Membuka kunci log masuk pengguna yang terkunci karena terlalu sering gagal.
+// Jeda per IP hanya dihapus jika IP dikirim, karena IP bisa dipakai bersama pengguna lain.
+func UnlockUser(c *fiber.Ctx) error {
+	type UnlockInput struct {
+		IP string `json:"ip"`
+	}
+
+	input := new(UnlockInput)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Invalid JSON format",
+				"data":    err.Error(),
+			})
+		}
+	}
+	if input.IP != "" && net.ParseIP(input.IP) == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid IP address",
+			"data":    nil,
+		})
+	}
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid user ID",
+			"data":    nil,
+		})
+	}
+
+	db := database.DB
+	var user model.User
+	if err := db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "User not found",
+				"data":    nil,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Database error",
+			"data":    err.Error(),
+		})
+	}
+
+	if msg := manageBlocked(c, &user, ""); msg != "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": msg,
+			"data":    nil,
+		})
+	}
+
+	clearLoginFailures(loginSubject("", &user))
+	if input.IP != "" {
+		clearIPLoginFailures(input.IP)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "User unlocked",
+		"data": fiber.Map{
+			"ip_cleared": input.IP != "",
+		},
+	})
+}
+
+// Data pengguna untuk admin (tanpa password dan secret)
+type adminUserData struct {
+	ID                    uuid.UUID  `json:"id"`
+	Name                  string     `json:"name"`
+	Username              string     `json:"username"`
+	Email                 string     `json:"email"`
+	Role                  string     `json:"role"`
+	EmailVerifiedAt       *time.Time `json:"email_verified_at"`
+	MFAEnabled            bool       `json:"mfa_enabled"`
+	DisabledAt            *time.Time `json:"disabled_at"`
+	PasswordResetRequired bool       `json:"password_reset_required"`
+	CreatedAt             time.Time  `json:"created_at"`
+	DeletedAt             *time.Time `json:"deleted_at,omitempty"`
+}
+
+func newAdminUserData(user *model.User) adminUserData {
+	data := adminUserData{
+		ID:                    user.ID,
+		Name:                  user.Name,
+		Username:              user.Username,
+		Email:                 user.Email,
+		Role:                  user.Role,
+		EmailVerifiedAt:       user.EmailVerifiedAt,
+		MFAEnabled:            user.MFAEnabledAt != nil,
+		DisabledAt:            user.DisabledAt,
+		PasswordResetRequired: user.PasswordResetRequired,
+		CreatedAt:             user.CreatedAt,
+	}
+	if user.DeletedAt.Valid {
+		data.DeletedAt = &user.DeletedAt.Time
+	}
+	return data
+}
+
+// Cari pengguna dari parameter :id, deleted menentukan apakah yang dicari pengguna yang sudah dihapus
+func adminFindUser(c *fiber.Ctx, deleted bool) (*model.User, error) {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	db := database.DB
+	if deleted {
+		db = db.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+
+	var user model.User
+	if err := db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "User not found")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Daftar pengguna dengan paginasi.
+// Filter: ?q= (nama, username atau email), ?role=, ?status=active|disabled, ?deleted=true untuk pengguna yang sudah dihapus
+func GetUsers(c *fiber.Ctx) error {
+	page, limit := pageParams(c)
+
+	query := database.DB.Model(&model.User{})
+	if c.QueryBool("deleted") {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		like := "%" + strings.ToLower(q) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR username LIKE ? OR LOWER(email) LIKE ?", like, like, like)
+	}
+	if role := c.Query("role"); role != "" {
+		query = query.Where("role = ?", role)
+	}
+	switch c.Query("status") {
+	case "active":
+		query = query.Where("disabled_at IS NULL")
+	case "disabled":
+		query = query.Where("disabled_at IS NOT NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Database error",
+			"data":    err.Error(),
+		})
+	}
+
+	var users []model.User
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&users).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Database error",
+			"data":    err.Error(),
+		})
+	}
+
+	items := make([]adminUserData, 0, len(users))
+	for i := range users {
+		items = append(items, newAdminUserData(&users[i]))
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Users found",
+		"data": fiber.Map{
+			"items": items,
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// Menonaktifkan akun, semua token pengguna ikut dicabut
+func DisableUser(c *fiber.Ctx) error {
+	user, err := adminFindUser(c, false)
+	if err != nil {
+		return err
+	}
+
+	if msg := manageBlocked(c, user, ""); msg != "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": msg,
+			"data":    nil,
+		})
+	}
+
+	if user.DisabledAt == nil {
+		now := time.Now()
+		if err := database.DB.Model(user).Update("disabled_at", &now).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Couldn't disable user",
+				"data":    err.Error(),
+			})
+		}
+	}
+
+	if err := token.RevokeUser(user.ID.String()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't revoke tokens",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "User disabled",
+		"data":    newAdminUserData(user),
+	})
+}
+
+// Mengaktifkan kembali akun yang dinonaktifkan
+func EnableUser(c *fiber.Ctx) error {
+	user, err := adminFindUser(c, false)
+	if err != nil {
+		return err
+	}
+
+	if msg := manageBlocked(c, user, ""); msg != "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": msg,
+			"data":    nil,
+		})
+	}
+
+	if err := database.DB.Model(user).Update("disabled_at", nil).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't enable user",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "User enabled",
+		"data":    newAdminUserData(user),
+	})
+}
+
+// Mewajibkan pengguna mengganti password: token dicabut, log masuk ditolak
+// sampai password di-reset, dan email reset dikirim
+func ForcePasswordReset(c *fiber.Ctx) error {
+	user, err := adminFindUser(c, false)
+	if err != nil {
+		return err
+	}
+
+	if msg := manageBlocked(c, user, ""); msg != "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": msg,
+			"data":    nil,
+		})
+	}
+
+	if err := database.DB.Model(user).Update("password_reset_required", true).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't update user",
+			"data":    err.Error(),
+		})
+	}
+
+	if err := token.RevokeUser(user.ID.String()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't revoke tokens",
+			"data":    err.Error(),
+		})
+	}
+
+	if err := sendPasswordReset(user); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't send reset email",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Password reset required, reset email sent",
+		"data":    newAdminUserData(user),
+	})
+}
+
+// Memulihkan pengguna yang sudah dihapus (soft delete)
+func RestoreUser(c *fiber.Ctx) error {
+	user, err := adminFindUser(c, true)
+	if err != nil {
+		return err
+	}
+
+	if msg := manageBlocked(c, user, ""); msg != "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": msg,
+			"data":    nil,
+		})
+	}
+
+	if err := database.DB.Unscoped().Model(user).Update("deleted_at", nil).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't restore user",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "User restored",
+		"data":    newAdminUserData(user),
+	})
+}
+
+// Menghapus permanen pengguna yang sudah dihapus (soft delete), beserta datanya
+func PurgeUser(c *fiber.Ctx) error {
+	user, err := adminFindUser(c, true)
+	if err != nil {
+		return err
+	}
+
+	if msg := manageBlocked(c, user, ""); msg != "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": msg,
+			"data":    nil,
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		tx = tx.Unscoped()
+		if err := tx.Where("user_id = ?", user.ID).Delete(&model.HasilAngket{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&model.UserIdentity{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(user).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't purge user",
+			"data":    err.Error(),
+		})
+	}
+
+	if err := token.RevokeUser(user.ID.String()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't revoke tokens",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "User permanently deleted",
+		"data":    nil,
+	})
+}
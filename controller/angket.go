@@ -2,35 +2,161 @@ package controller
 
 import (
 	"fmt"
+	"jalurku/config"
 	"jalurku/database"
 	"jalurku/model"
+	"jalurku/token"
+	"math"
 	"math/rand"
+	"strconv"
 	"time"
 
-	"context"
-	"encoding/json"
-
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// Minimal bagian pertanyaan yang harus dijawab sebelum selesai (0-1, default 1 = semua)
+func angketMinCompletion() float64 {
+	v, err := strconv.ParseFloat(config.ConfigWithDefault("ANGKET_MIN_COMPLETION", "1"), 64)
+	if err != nil || v < 0 || v > 1 {
+		return 1
+	}
+	return v
+}
+
 // Membuat sesi angket baru, dan disimpan di Redis.
+// Pertanyaan untuk sesi ini dipilih acak oleh server (ANGKET_JUMLAH_PERTANYAAN, 0 = semua).
+// Sesi terikat ke pengguna yang log masuk, atau ke token perangkat tamu (header X-Device-Token,
+// dibuat server jika belum ada) agar bisa dilanjutkan lewat /angket/aktif.
 // Sesi akan hilang, jika tidak digunakan dalam jangka waktu 1 jam
 func StartAngket(c *fiber.Ctx) error {
 	sessionID := uuid.New().String()
 
-	ctx := context.Background()
-	key := fmt.Sprintf("session:%s:started", sessionID)
+	// Tamu tanpa token perangkat diberi token baru
+	var deviceToken string
+	owner := angketOwner(c)
+	if owner == "" {
+		var err error
+		if deviceToken, err = token.Random(); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "gagal membuat session"})
+		}
+		owner = "device:" + token.Hash(deviceToken)
+	}
+
+	var ids []string
+	query := database.DB.Model(&model.Pertanyaan{}).Order("RANDOM()")
+	if n := config.IntWithDefault("ANGKET_JUMLAH_PERTANYAAN", 0); n > 0 {
+		query = query.Limit(n)
+	}
+	if err := query.Pluck("id", &ids).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "gagal mengambil pertanyaan"})
+	}
+	if len(ids) == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "belum ada pertanyaan"})
+	}
 
 	// simpan di Redis (berlaku 1 jam)
-	if err := database.RedisClient.Set(ctx, key, true, time.Hour).Err(); err != nil {
+	meta := map[string]interface{}{
+		"started_at": time.Now().Unix(),
+		"owner":      owner,
+		"version":    angketSessionVersion,
+	}
+	if err := createAngketSession(sessionID, ids, meta); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "gagal membuat session"})
 	}
 
-	return c.JSON(fiber.Map{
+	res := fiber.Map{
 		"message":    "Session angket dimulai",
 		"session_id": sessionID,
+		"pertanyaan": ids,
+	}
+	if deviceToken != "" {
+		res["device_token"] = deviceToken
+	}
+	return c.JSON(res)
+}
+
+// Mencari sesi angket yang belum selesai milik pengguna atau perangkat ini
+func GetAngketAktif(c *fiber.Ctx) error {
+	// Sesi milik pengguna diutamakan, lalu sesi milik perangkat
+	var sessionID string
+	for _, owner := range angketOwners(c) {
+		id, err := activeAngketSession(owner)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "gagal memeriksa session"})
+		}
+		if id != "" {
+			sessionID = id
+			break
+		}
+	}
+	if sessionID == "" {
+		return c.Status(404).JSON(fiber.Map{"error": "tidak ada session aktif"})
+	}
+
+	meta, err := angketMeta(sessionID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "gagal memeriksa session"})
+	}
+	if len(meta) == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "tidak ada session aktif"})
+	}
+
+	questionIDs, err := angketQuestions(sessionID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "gagal mengambil pertanyaan"})
+	}
+	jawaban, err := loadJawaban(sessionID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "gagal mengambil jawaban"})
+	}
+
+	startedAt, _ := strconv.ParseInt(meta["started_at"], 10, 64)
+	return c.JSON(fiber.Map{
+		"session_id": sessionID,
+		"started_at": time.Unix(startedAt, 0),
+		"terjawab":   len(jawaban),
+		"total":      len(questionIDs),
+	})
+}
+
+// Progres sesi angket: jumlah terjawab, pertanyaan yang tersisa (sesuai urutan sesi),
+// dan pilihan yang sudah dijawab, agar klien bisa melanjutkan
+func GetAngketProgress(c *fiber.Ctx) error {
+	sessionID := c.Params("session_id")
+
+	meta, err := angketMeta(sessionID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "gagal memeriksa session"})
+	}
+	if len(meta) == 0 || !ownsAngketSession(c, meta) {
+		return c.Status(404).JSON(fiber.Map{"error": "session tidak valid atau sudah expired"})
+	}
+
+	questionIDs, err := angketQuestions(sessionID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "gagal mengambil pertanyaan"})
+	}
+	jawaban, err := loadJawaban(sessionID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "gagal mengambil jawaban"})
+	}
+
+	sisa := []string{}
+	for _, id := range questionIDs {
+		if _, ok := jawaban[id]; !ok {
+			sisa = append(sisa, id)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"session_id": sessionID,
+		"terjawab":   len(jawaban),
+		"total":      len(questionIDs),
+		"pertanyaan": questionIDs,
+		"sisa":       sisa,
+		"jawaban":    jawaban,
 	})
 }
 
@@ -41,47 +167,66 @@ func SubmitJawaban(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
 	}
 
-	ctx := context.Background()
-
 	// Apakah sesi valid?
-	sessionKey := fmt.Sprintf("session:%s:started", req.SessionID)
-	exists, err := database.RedisClient.Exists(ctx, sessionKey).Result()
+	meta, err := angketMeta(req.SessionID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "gagal memeriksa session"})
 	}
-	if exists == 0 {
+	if len(meta) == 0 {
 		return c.Status(403).JSON(fiber.Map{"error": "session tidak valid atau sudah expired"})
 	}
-
-	// Apakah pertanyaannya valid?
-	var q model.Pertanyaan
-	if err := database.DB.Where("id = ?", req.QuestionID).First(&q).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "pertanyaan tidak ditemukan"})
+	if !ownsAngketSession(c, meta) {
+		return c.Status(404).JSON(fiber.Map{"error": "session tidak valid atau sudah expired"})
 	}
 
-	// 💾 Simpan jawaban 
-	dataKey := fmt.Sprintf("session:%s:started", req.SessionID)
-	existing, _ := database.RedisClient.Get(ctx, dataKey).Result()
-	var sessionData []model.SubmitRequest
-	if existing != "" {
-		json.Unmarshal([]byte(existing), &sessionData)
+	if status, res := cekJawaban(req.SessionID, req.QuestionID, req.SelectedOption); res != nil {
+		return c.Status(status).JSON(res)
 	}
-	sessionData = append(sessionData, req)
-	jsonData, _ := json.Marshal(sessionData)
 
-	if err := database.RedisClient.Set(ctx, dataKey, jsonData, time.Hour).Err(); err != nil {
+	// 💾 Simpan jawaban, jawaban lama untuk pertanyaan yang sama diganti
+	replaced, stale, err := saveJawaban(req.SessionID, meta["owner"], req.QuestionID, req.SelectedOption, time.Now())
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "gagal menyimpan jawaban"})
 	}
-
-	// ⏱️ Perpanjang juga TTL session utama
-	database.RedisClient.Expire(ctx, sessionKey, time.Hour)
+	if stale {
+		return c.Status(409).JSON(fiber.Map{"error": "jawaban yang lebih baru sudah tersimpan"})
+	}
 
 	return c.JSON(fiber.Map{
 		"message": "Jawaban tersimpan dan session diperpanjang",
 		"data":    req,
+		"diganti": replaced,
 	})
 }
 
+// Memeriksa satu jawaban untuk sesi yang valid. Mengembalikan status dan isi response error,
+// atau res nil jika jawaban boleh disimpan.
+func cekJawaban(sessionID, questionID string, option int) (status int, res fiber.Map) {
+	// Apakah pertanyaan termasuk pertanyaan yang diberikan ke sesi ini?
+	assigned, err := angketAssigned(sessionID, questionID)
+	if err != nil {
+		return 500, fiber.Map{"error": "gagal memeriksa pertanyaan"}
+	}
+	if !assigned {
+		return 400, fiber.Map{"error": "pertanyaan bukan bagian dari sesi ini"}
+	}
+
+	// Apakah pertanyaannya valid?
+	var q model.Pertanyaan
+	if err := database.DB.Preload("Skala.Opsi", model.PreloadOpsi).Where("id = ?", questionID).First(&q).Error; err != nil {
+		return 404, fiber.Map{"error": "pertanyaan tidak ditemukan"}
+	}
+
+	// Apakah jawabannya termasuk pilihan dari skala pertanyaan?
+	if _, ok := q.Skala.FindOpsi(option); !ok {
+		return 400, fiber.Map{
+			"error": "pilihan jawaban tidak valid",
+			"opsi":  q.Skala.Opsi,
+		}
+	}
+	return 0, nil
+}
+
 // Jika semua angket sudah terjawab,
 // maka hapus sesi angketnya,
 // dan hitung skor angketnya
@@ -95,43 +240,71 @@ func FinishAngket(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
 	}
 
-	ctx := context.Background()
-	key := fmt.Sprintf("session:%s:started", req.SessionID)
-
-	// Ambil semua jawaban dari Redis
-	data, err := database.RedisClient.Get(ctx, key).Result()
+	meta, err := angketMeta(req.SessionID)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "data sesi tidak ditemukan"})
+		return c.Status(500).JSON(fiber.Map{"error": "gagal memeriksa session"})
+	}
+	if !ownsAngketSession(c, meta) {
+		return c.Status(404).JSON(fiber.Map{"error": "session tidak valid atau sudah expired"})
 	}
 
-	var answers []model.SubmitRequest
-	json.Unmarshal([]byte(data), &answers)
+	// Ambil daftar pertanyaan dan semua jawaban dari Redis
+	questionIDs, err := angketQuestions(req.SessionID)
+	if err != nil || len(questionIDs) == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "data sesi tidak ditemukan"})
+	}
 
-	// Map jurusan_id -> total skor
-	skorJurusan := make(map[int]int)
+	jawaban, err := loadJawaban(req.SessionID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "gagal mengambil jawaban"})
+	}
 
-	for _, ans := range answers {
-		var p model.Pertanyaan
-		if err := database.DB.First(&p, "id = ?", ans.QuestionID).Error; err != nil {
-			continue
+	// Satu jawaban per pertanyaan, sesuai urutan pertanyaan sesi
+	var answers []model.SubmitRequest
+	for _, id := range questionIDs {
+		if option, ok := jawaban[id]; ok {
+			answers = append(answers, model.SubmitRequest{
+				SessionID:      req.SessionID,
+				QuestionID:     id,
+				SelectedOption: option,
+			})
 		}
-		skorJurusan[p.JurusanID] += ans.SelectedOption
 	}
 
+	// Tolak jika jawaban belum mencapai batas minimal
+	minimal := int(math.Ceil(angketMinCompletion() * float64(len(questionIDs))))
+	if len(answers) < minimal {
+		return c.Status(400).JSON(fiber.Map{
+			"error":    "angket belum lengkap",
+			"terjawab": len(answers),
+			"total":    len(questionIDs),
+			"minimal":  minimal,
+		})
+	}
+
+	// Map jurusan_id -> skor
+	nilai := hitungSkor(answers)
+
 	// Jika tidak ada data valid
-	if len(skorJurusan) == 0 {
+	if len(nilai) == 0 {
 		return c.Status(400).JSON(fiber.Map{"error": "tidak ada jawaban valid"})
 	}
 
-	// Cari skor tertinggi
-	maxScore := 0
+	// Map jurusan_id -> persentase skor
+	skorJurusan := make(map[int]float64)
+	for jurusanID, n := range nilai {
+		skorJurusan[jurusanID] = n.Persen()
+	}
+
+	// Cari persentase tertinggi
+	maxScore := math.Inf(-1)
 	for _, total := range skorJurusan {
 		if total > maxScore {
 			maxScore = total
 		}
 	}
 
-	// Ambil semua jurusan yang punya skor tertinggi
+	// Ambil semua jurusan yang punya persentase tertinggi
 	var kandidat []int
 	for jurusanID, total := range skorJurusan {
 		if total == maxScore {
@@ -147,23 +320,23 @@ func FinishAngket(c *fiber.Ctx) error {
 	var jurusanTerbaik model.Jurusan
 	database.DB.First(&jurusanTerbaik, "id = ?", chosenJurusanID)
 
-	// Hapus Redis
-	if err := database.RedisClient.Del(ctx, key).Err(); err != nil {
-    	fmt.Println("⚠️ gagal menghapus redis key:", err)
-	}
-
-	// 🔐 Cek apakah user login
-	var userID uuid.UUID
-	userToken := c.Locals("user")
-	if userToken != nil {
-		token := userToken.(*jwt.Token)
-		claims := token.Claims.(jwt.MapClaims)
-		uidStr, ok := claims["user_id"].(string)
-		if ok {
-			parsedID, err := uuid.Parse(uidStr)
-			if err == nil {
-				userID = parsedID
-			}
+	// Hapus Redis, hanya satu request yang bisa mengakhiri sesi (misalnya double-tap)
+	ended, err := endAngketSession(req.SessionID, meta["owner"])
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "gagal mengakhiri session"})
+	}
+	if !ended {
+		return c.Status(409).JSON(fiber.Map{"error": "session sudah selesai"})
+	}
+
+	// 🔐 Hasil disimpan untuk pengguna pemilik sesi
+	userID := angketOwnerUserID(meta)
+
+	// 📧 Hasil pengguna yang belum verifikasi email bisa tidak disimpan
+	if userID != uuid.Nil && !keepUnverifiedResults() {
+		var u model.User
+		if err := database.DB.Select("email_verified_at").First(&u, "id = ?", userID).Error; err != nil || u.EmailVerifiedAt == nil {
+			userID = uuid.Nil
 		}
 	}
 
@@ -192,6 +365,7 @@ func FinishAngket(c *fiber.Ctx) error {
 			"jurusan_terbaik":  jurusanTerbaik.Name,
 			"total_skor":       maxScore,
 			"detail_skor":      skorJurusan,
+			"skor_mentah":      nilai,
 		},
 	})
 }
@@ -229,6 +403,7 @@ func GetPertanyaanByID(c *fiber.Ctx) error {
 	db := database.DB
 
 	if err := db.Preload("Jurusan").
+		Preload("Skala.Opsi", model.PreloadOpsi).
 		Where("id = ?", id).
 		First(&pertanyaan).Error; err != nil {
 
@@ -266,11 +441,29 @@ func CreatePertanyaan(c *fiber.Ctx) error {
 		})
 	}
 
+	// reverse tidak ikut terbaca dari model.Pertanyaan karena disembunyikan dari siswa
+	var flags struct {
+		Reverse bool `json:"reverse"`
+	}
+	c.BodyParser(&flags)
+	input.Reverse = flags.Reverse
+
 	if input.ID == uuid.Nil {
 		input.ID = uuid.New()
 	}
 
-	if err := db.Create(&input).Error; err != nil {
+	// Skala default Likert 1-5, skala harus sudah ada
+	if input.SkalaID == 0 {
+		input.SkalaID = model.SkalaLikertID
+	}
+	if err := db.Preload("Opsi", model.PreloadOpsi).First(&input.Skala, input.SkalaID).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Skala tidak ditemukan",
+		})
+	}
+
+	if err := db.Omit("Skala").Create(&input).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"status":  "error",
 			"message": "Gagal membuat pertanyaan",
@@ -318,6 +511,12 @@ func UpdatePertanyaan(c *fiber.Ctx) error {
 		})
 	}
 
+	// reverse dibaca terpisah, karena disembunyikan dari siswa dan agar nilai false juga bisa disimpan
+	var flags struct {
+		Reverse *bool `json:"reverse"`
+	}
+	c.BodyParser(&flags)
+
 	// Update field yang boleh diubah
 	if updateData.Text != "" {
 		pertanyaan.Text = updateData.Text
@@ -325,6 +524,19 @@ func UpdatePertanyaan(c *fiber.Ctx) error {
 	if updateData.JurusanID != 0 {
 		pertanyaan.JurusanID = updateData.JurusanID
 	}
+	if updateData.SkalaID != 0 {
+		var skala model.Skala
+		if err := db.First(&skala, updateData.SkalaID).Error; err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Skala tidak ditemukan",
+			})
+		}
+		pertanyaan.SkalaID = updateData.SkalaID
+	}
+	if flags.Reverse != nil {
+		pertanyaan.Reverse = *flags.Reverse
+	}
 
 	if err := db.Save(&pertanyaan).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{
@@ -366,7 +578,14 @@ func DeletePertanyaan(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := db.Delete(&pertanyaan).Error; err != nil {
+	// Bobot jurusan ikut dihapus bersama pertanyaannya
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("pertanyaan_id = ?", pertanyaan.ID).Delete(&model.BobotJurusan{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&pertanyaan).Error
+	})
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"status":  "error",
 			"message": "Gagal menghapus pertanyaan",
@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"jalurku/model"
+	"jalurku/token"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// Batas jumlah jawaban dalam satu batch
+	angketBatchMax = 500
+	// Toleransi selisih jam perangkat klien terhadap server
+	angketClockSkew = 5 * time.Minute
+)
+
+// Submit banyak jawaban sekaligus untuk klien offline.
+// Setiap jawaban diperiksa sendiri-sendiri, jawaban yang valid tetap disimpan walaupun ada yang ditolak.
+// Jika satu pertanyaan dijawab lebih dari sekali, termasuk lewat submit sebelumnya,
+// jawaban dengan answered_at terbaru yang dipakai.
+// Request ulang dengan idempotency_key dan isi yang sama mendapat response yang sama tanpa diproses lagi,
+// idempotency_key yang dipakai ulang untuk isi berbeda ditolak.
+func SubmitJawabanBatch(c *fiber.Ctx) error {
+	var req model.BatchSubmitRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = c.Get("Idempotency-Key")
+	}
+	if req.SessionID == "" || req.IdempotencyKey == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "session_id dan idempotency_key wajib diisi"})
+	}
+	if len(req.Answers) == 0 || len(req.Answers) > angketBatchMax {
+		return c.Status(400).JSON(fiber.Map{"error": "jumlah jawaban harus 1-" + strconv.Itoa(angketBatchMax)})
+	}
+
+	// Pemilik diperiksa sebelum idempotency key dipakai
+	meta, err := angketMeta(req.SessionID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "gagal memeriksa session"})
+	}
+	if len(meta) > 0 && !ownsAngketSession(c, meta) {
+		return c.Status(404).JSON(fiber.Map{"error": "session tidak valid atau sudah expired"})
+	}
+
+	// Hash isi batch, agar key yang sama tidak dipakai untuk jawaban lain
+	answers, _ := json.Marshal(req.Answers)
+	entry := angketBatchEntry{
+		Owner:   meta["owner"],
+		Version: meta["version"],
+		Request: token.Hash(string(answers)),
+	}
+
+	// Batch yang sama sudah pernah dikirim?
+	claimed, cached, err := claimAngketBatch(req.SessionID, req.IdempotencyKey, entry)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "gagal memeriksa idempotency key"})
+	}
+	if !claimed {
+		switch {
+		case cached == nil:
+			return c.Status(409).JSON(fiber.Map{"error": "batch sedang diproses"})
+		case !ownsAngketSession(c, cached.meta()):
+			return c.Status(404).JSON(fiber.Map{"error": "session tidak valid atau sudah expired"})
+		case cached.Request != entry.Request:
+			return c.Status(422).JSON(fiber.Map{"error": "idempotency_key sudah dipakai untuk batch lain"})
+		case len(cached.Response) == 0:
+			return c.Status(409).JSON(fiber.Map{"error": "batch sedang diproses"})
+		}
+		c.Set("Idempotent-Replayed", "true")
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(cached.Response)
+	}
+
+	// Apakah sesi valid?
+	if len(meta) == 0 {
+		releaseAngketBatch(req.SessionID, req.IdempotencyKey)
+		return c.Status(403).JSON(fiber.Map{"error": "session tidak valid atau sudah expired"})
+	}
+
+	now := time.Now()
+	startedAt, _ := strconv.ParseInt(meta["started_at"], 10, 64)
+	mulai := time.Unix(startedAt, 0)
+
+	// Pilih jawaban terbaru per pertanyaan, jawaban lain diabaikan
+	hasil := make([]fiber.Map, len(req.Answers))
+	terbaru := make(map[string]int)
+	for i, a := range req.Answers {
+		hasil[i] = fiber.Map{"index": i, "question_id": a.QuestionID}
+
+		if a.AnsweredAt.IsZero() {
+			req.Answers[i].AnsweredAt = now
+		} else if a.AnsweredAt.After(now.Add(angketClockSkew)) || a.AnsweredAt.Before(mulai.Add(-angketClockSkew)) {
+			hasil[i]["status"] = "ditolak"
+			hasil[i]["error"] = "waktu menjawab di luar masa sesi"
+			continue
+		} else if a.AnsweredAt.After(now) {
+			// Jam perangkat yang lebih cepat tidak boleh mengalahkan jawaban online berikutnya
+			req.Answers[i].AnsweredAt = now
+		}
+
+		if j, ok := terbaru[a.QuestionID]; ok && req.Answers[j].AnsweredAt.After(req.Answers[i].AnsweredAt) {
+			hasil[i]["status"] = "diabaikan"
+			continue
+		} else if ok {
+			hasil[j]["status"] = "diabaikan"
+		}
+		terbaru[a.QuestionID] = i
+	}
+
+	// Periksa dan simpan setiap jawaban terpilih
+	var tersimpan, ditolak int
+	serverError := false
+	for i, a := range req.Answers {
+		if hasil[i]["status"] != nil {
+			if hasil[i]["status"] == "ditolak" {
+				ditolak++
+			}
+			continue
+		}
+
+		if status, res := cekJawaban(req.SessionID, a.QuestionID, a.SelectedOption); res != nil {
+			for k, v := range res {
+				hasil[i][k] = v
+			}
+			hasil[i]["status"] = "ditolak"
+			serverError = serverError || status >= 500
+			ditolak++
+			continue
+		}
+
+		replaced, stale, err := saveJawaban(req.SessionID, meta["owner"], a.QuestionID, a.SelectedOption, req.Answers[i].AnsweredAt)
+		if err != nil {
+			hasil[i]["status"] = "ditolak"
+			hasil[i]["error"] = "gagal menyimpan jawaban"
+			serverError = true
+			ditolak++
+			continue
+		}
+		if stale {
+			// Jawaban yang tersimpan (misalnya dari submit online) lebih baru
+			hasil[i]["status"] = "diabaikan"
+			continue
+		}
+		hasil[i]["status"] = "tersimpan"
+		hasil[i]["diganti"] = replaced
+		tersimpan++
+	}
+
+	response := fiber.Map{
+		"message":    "Batch jawaban diproses",
+		"session_id": req.SessionID,
+		"tersimpan":  tersimpan,
+		"ditolak":    ditolak,
+		"hasil":      hasil,
+	}
+
+	// Batch dengan error server boleh dikirim ulang dengan key yang sama
+	body, err := json.Marshal(response)
+	entry.Response = body
+	if err != nil || serverError {
+		releaseAngketBatch(req.SessionID, req.IdempotencyKey)
+	} else if err := storeAngketBatch(req.SessionID, req.IdempotencyKey, entry); err != nil {
+		releaseAngketBatch(req.SessionID, req.IdempotencyKey)
+	}
+
+	return c.JSON(response)
+}
@@ -0,0 +1,296 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"jalurku/database"
+	"jalurku/token"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Key Redis sesi angket. Metadata dan jawaban disimpan terpisah,
+// setiap jawaban adalah satu field hash sehingga tersimpan secara atomik.
+//
+//	angket:<id>:meta        -> hash metadata sesi (started_at, owner, version)
+//	angket:<id>:questions   -> daftar ID pertanyaan yang diberikan saat mulai (urut)
+//	angket:<id>:answers     -> hash question_id -> selected_option
+//	angket:<id>:answered_at -> hash question_id -> waktu menjawab (unix milidetik)
+//	angket_aktif:<owner>    -> ID sesi yang belum selesai milik pengguna atau perangkat
+//	angket_batch:<id>:<hash idempotency key> -> pemilik, hash isi dan response submit batch
+const (
+	angketTTL          = time.Hour
+	angketActivePrefix = "angket_aktif:"
+	angketBatchPrefix  = "angket_batch:"
+
+	// Batas waktu tanda batch yang sedang diproses, agar request yang gagal di tengah jalan
+	// tidak mengunci idempotency key selama angketTTL. Diperpanjang setelah response disimpan.
+	angketBatchClaimTTL = time.Minute
+
+	// Versi metadata sesi, sesi tanpa version dan owner (sebelum sesi terikat ke pemilik) ditolak
+	angketSessionVersion = "2"
+)
+
+// Header untuk perangkat tamu (tanpa log masuk), nilainya dibuat server saat sesi pertama dimulai
+const HeaderDeviceToken = "X-Device-Token"
+
+// Identitas pemanggil yang bisa memiliki sesi: "user:<user_id>" jika log masuk,
+// lalu "device:<hash>" jika ada token perangkat tamu
+func angketOwners(c *fiber.Ctx) []string {
+	var owners []string
+	if t, ok := c.Locals("user").(*jwt.Token); ok {
+		if uid, ok := t.Claims.(jwt.MapClaims)["user_id"].(string); ok {
+			owners = append(owners, "user:"+uid)
+		}
+	}
+	if device := c.Get(HeaderDeviceToken); device != "" {
+		owners = append(owners, "device:"+token.Hash(device))
+	}
+	return owners
+}
+
+// Pemilik untuk sesi baru, pengguna yang log masuk diutamakan. Kosong jika tamu tanpa token perangkat.
+func angketOwner(c *fiber.Ctx) string {
+	if owners := angketOwners(c); len(owners) > 0 {
+		return owners[0]
+	}
+	return ""
+}
+
+// Apakah request berasal dari pemilik sesi?
+func ownsAngketSession(c *fiber.Ctx, meta map[string]string) bool {
+	owner := meta["owner"]
+	if owner == "" {
+		return false
+	}
+	for _, o := range angketOwners(c) {
+		if o == owner {
+			return true
+		}
+	}
+	return false
+}
+
+// ID pengguna pemilik sesi, uuid.Nil jika sesi milik perangkat tamu
+func angketOwnerUserID(meta map[string]string) uuid.UUID {
+	uid, ok := strings.CutPrefix(meta["owner"], "user:")
+	if !ok {
+		return uuid.Nil
+	}
+	id, err := uuid.Parse(uid)
+	if err != nil {
+		return uuid.Nil
+	}
+	return id
+}
+
+// ID sesi yang belum selesai milik pemilik, kosong jika tidak ada
+func activeAngketSession(owner string) (string, error) {
+	sessionID, err := database.RedisClient.Get(context.Background(), angketActivePrefix+owner).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return sessionID, err
+}
+
+func angketKey(sessionID, part string) string {
+	return fmt.Sprintf("angket:%s:%s", sessionID, part)
+}
+
+// Semua key milik sesi angket
+func angketKeys(sessionID string) []string {
+	return []string{
+		angketKey(sessionID, "meta"),
+		angketKey(sessionID, "questions"),
+		angketKey(sessionID, "answers"),
+		angketKey(sessionID, "answered_at"),
+	}
+}
+
+// Menyimpan sesi baru beserta daftar pertanyaannya
+func createAngketSession(sessionID string, questionIDs []string, meta map[string]interface{}) error {
+	ctx := context.Background()
+
+	questions := make([]interface{}, len(questionIDs))
+	for i, id := range questionIDs {
+		questions[i] = id
+	}
+
+	pipe := database.RedisClient.TxPipeline()
+	pipe.HSet(ctx, angketKey(sessionID, "meta"), meta)
+	pipe.RPush(ctx, angketKey(sessionID, "questions"), questions...)
+	for _, key := range angketKeys(sessionID)[:2] {
+		pipe.Expire(ctx, key, angketTTL)
+	}
+	// Sesi baru menggantikan sesi aktif sebelumnya milik pemilik yang sama
+	if owner, _ := meta["owner"].(string); owner != "" {
+		pipe.Set(ctx, angketActivePrefix+owner, sessionID, angketTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Metadata sesi, kosong jika sesi tidak ada atau sudah expired
+func angketMeta(sessionID string) (map[string]string, error) {
+	return database.RedisClient.HGetAll(context.Background(), angketKey(sessionID, "meta")).Result()
+}
+
+// Daftar ID pertanyaan sesi sesuai urutan saat mulai
+func angketQuestions(sessionID string) ([]string, error) {
+	return database.RedisClient.LRange(context.Background(), angketKey(sessionID, "questions"), 0, -1).Result()
+}
+
+// Apakah pertanyaan termasuk pertanyaan yang diberikan ke sesi?
+func angketAssigned(sessionID, questionID string) (bool, error) {
+	_, err := database.RedisClient.LPos(context.Background(), angketKey(sessionID, "questions"), questionID, redis.LPosArgs{}).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Menyimpan jawaban beserta waktu menjawab, kecuali jawaban yang tersimpan lebih baru.
+// Mengembalikan -1 jika jawaban lebih lama, 0 jika mengganti jawaban lama, 1 jika jawaban baru.
+var saveJawabanScript = redis.NewScript(`
+local prev = redis.call('HGET', KEYS[2], ARGV[1])
+if prev and tonumber(prev) > tonumber(ARGV[3]) then
+	return -1
+end
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[3])
+return redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+`)
+
+// Menyimpan jawaban, jawaban lama untuk pertanyaan yang sama diganti jika answeredAt
+// tidak lebih lama dari jawaban lama. Semua key sesi (dan penanda sesi aktif pemilik) diperpanjang.
+// replaced bernilai true jika jawaban lama diganti, stale bernilai true jika jawaban
+// tidak disimpan karena jawaban yang tersimpan lebih baru.
+func saveJawaban(sessionID, owner, questionID string, option int, answeredAt time.Time) (replaced, stale bool, err error) {
+	ctx := context.Background()
+
+	pipe := database.RedisClient.TxPipeline()
+	res := saveJawabanScript.Eval(ctx, pipe,
+		[]string{angketKey(sessionID, "answers"), angketKey(sessionID, "answered_at")},
+		questionID, option, answeredAt.UnixMilli(),
+	)
+	for _, key := range angketKeys(sessionID) {
+		pipe.Expire(ctx, key, angketTTL)
+	}
+	if owner != "" {
+		pipe.Expire(ctx, angketActivePrefix+owner, angketTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, false, err
+	}
+
+	n, err := res.Int()
+	if err != nil {
+		return false, false, err
+	}
+	return n == 0, n < 0, nil
+}
+
+// Semua jawaban sesi, question_id -> selected_option
+func loadJawaban(sessionID string) (map[string]int, error) {
+	data, err := database.RedisClient.HGetAll(context.Background(), angketKey(sessionID, "answers")).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	answers := make(map[string]int, len(data))
+	for questionID, v := range data {
+		option, err := strconv.Atoi(v)
+		if err != nil {
+			continue
+		}
+		answers[questionID] = option
+	}
+	return answers, nil
+}
+
+// Mengakhiri sesi. ended bernilai false jika sesi sudah diakhiri request lain.
+func endAngketSession(sessionID, owner string) (ended bool, err error) {
+	ctx := context.Background()
+	rdb := database.RedisClient
+
+	pipe := rdb.TxPipeline()
+	meta := pipe.Del(ctx, angketKey(sessionID, "meta"))
+	pipe.Del(ctx, angketKeys(sessionID)[1:]...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+
+	// Penanda sesi aktif dihapus jika masih menunjuk ke sesi ini
+	if owner != "" {
+		if active, _ := activeAngketSession(owner); active == sessionID {
+			rdb.Del(ctx, angketActivePrefix+owner)
+		}
+	}
+	return meta.Val() > 0, nil
+}
+
+func angketBatchKey(sessionID, idempotencyKey string) string {
+	return angketBatchPrefix + sessionID + ":" + token.Hash(idempotencyKey)
+}
+
+// Catatan submit batch untuk satu idempotency key. Response kosong selama batch masih diproses.
+type angketBatchEntry struct {
+	Owner    string          `json:"owner"`
+	Version  string          `json:"version"`
+	Request  string          `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// Metadata pemilik batch, untuk diperiksa dengan ownsAngketSession
+func (e *angketBatchEntry) meta() map[string]string {
+	return map[string]string{"owner": e.Owner, "version": e.Version}
+}
+
+// Menandai batch sedang diproses. Jika key sudah pernah dipakai, mengembalikan
+// catatan batch sebelumnya (cached nil jika catatan baru saja hilang).
+func claimAngketBatch(sessionID, idempotencyKey string, entry angketBatchEntry) (claimed bool, cached *angketBatchEntry, err error) {
+	ctx := context.Background()
+	key := angketBatchKey(sessionID, idempotencyKey)
+
+	data, _ := json.Marshal(entry)
+	claimed, err = database.RedisClient.SetNX(ctx, key, data, angketBatchClaimTTL).Result()
+	if err != nil || claimed {
+		return claimed, nil, err
+	}
+
+	raw, err := database.RedisClient.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, err
+	}
+	cached = new(angketBatchEntry)
+	if err := json.Unmarshal([]byte(raw), cached); err != nil {
+		return false, nil, err
+	}
+	return false, cached, nil
+}
+
+// Menyimpan response batch untuk request ulang dengan idempotency key dan isi yang sama,
+// tanda batch diperpanjang menjadi angketTTL
+func storeAngketBatch(sessionID, idempotencyKey string, entry angketBatchEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return database.RedisClient.Set(context.Background(), angketBatchKey(sessionID, idempotencyKey), data, angketTTL).Err()
+}
+
+// Melepas tanda batch agar bisa dikirim ulang (misalnya setelah error server)
+func releaseAngketBatch(sessionID, idempotencyKey string) {
+	database.RedisClient.Del(context.Background(), angketBatchKey(sessionID, idempotencyKey))
+}
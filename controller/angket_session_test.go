@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSaveJawabanKeepsNewestAnswer(t *testing.T) {
+	sessionID := "test-" + time.Now().Format("150405.000000000")
+	t.Cleanup(func() { endAngketSession(sessionID, "") })
+	now := time.Now()
+
+	// Jawaban online tersimpan lebih dulu
+	if _, stale, err := saveJawaban(sessionID, "", "q1", 4, now); err != nil || stale {
+		t.Fatalf("online answer: stale %v, err %v", stale, err)
+	}
+
+	// Jawaban offline yang lebih lama disinkronkan belakangan dan tidak mengganti jawaban online
+	if _, stale, err := saveJawaban(sessionID, "", "q1", 1, now.Add(-time.Minute)); err != nil || !stale {
+		t.Fatalf("older answer: stale %v, err %v, want stale", stale, err)
+	}
+	if answers, _ := loadJawaban(sessionID); answers["q1"] != 4 {
+		t.Fatalf("answer %d, want 4", answers["q1"])
+	}
+
+	// Jawaban yang lebih baru mengganti jawaban lama
+	replaced, stale, err := saveJawaban(sessionID, "", "q1", 2, now.Add(time.Minute))
+	if err != nil || stale || !replaced {
+		t.Fatalf("newer answer: replaced %v, stale %v, err %v", replaced, stale, err)
+	}
+	if answers, _ := loadJawaban(sessionID); answers["q1"] != 2 {
+		t.Fatalf("answer %d, want 2", answers["q1"])
+	}
+
+	for _, key := range angketKeys(sessionID)[2:] {
+		if ttl := testRedis.TTL(key); ttl <= 0 || ttl > angketTTL {
+			t.Fatalf("%s TTL %v, want up to %v", key, ttl, angketTTL)
+		}
+	}
+}
+
+func TestAngketBatchClaimExpires(t *testing.T) {
+	sessionID := "test-" + time.Now().Format("150405.000000000")
+	t.Cleanup(func() { releaseAngketBatch(sessionID, "batch-1") })
+	entry := angketBatchEntry{Owner: "device:abc", Version: angketSessionVersion, Request: "hash"}
+
+	if claimed, _, err := claimAngketBatch(sessionID, "batch-1", entry); err != nil || !claimed {
+		t.Fatalf("claim: claimed %v, err %v", claimed, err)
+	}
+	if ttl := testRedis.TTL(angketBatchKey(sessionID, "batch-1")); ttl > angketBatchClaimTTL {
+		t.Fatalf("claim TTL %v, want at most %v", ttl, angketBatchClaimTTL)
+	}
+
+	// Request yang terputus sebelum response disimpan tidak mengunci key selama angketTTL
+	testRedis.FastForward(angketBatchClaimTTL + time.Second)
+	if claimed, _, err := claimAngketBatch(sessionID, "batch-1", entry); err != nil || !claimed {
+		t.Fatalf("claim after expiry: claimed %v, err %v", claimed, err)
+	}
+
+	// Setelah response disimpan, key berlaku selama angketTTL
+	entry.Response = []byte(`{}`)
+	if err := storeAngketBatch(sessionID, "batch-1", entry); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := testRedis.TTL(angketBatchKey(sessionID, "batch-1")); ttl != angketTTL {
+		t.Fatalf("stored TTL %v, want %v", ttl, angketTTL)
+	}
+}
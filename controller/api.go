@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"jalurku/token"
+
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -11,4 +13,10 @@ import (
 // Contoh API
 func Hello(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"status": "success", "message": "Hello i'm ok!", "data": nil})
-}
\ No newline at end of file
+}
+
+// Kunci publik untuk memverifikasi token jalurku (JWKS), dipakai layanan sekolah lain
+func JWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(token.JWKS())
+}
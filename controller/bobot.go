@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"jalurku/database"
+	"jalurku/model"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Cari pertanyaan dari parameter :id beserta bobot dan skor pilihannya
+func findPertanyaanBobot(c *fiber.Ctx) (*model.Pertanyaan, error) {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, fiber.NewError(400, "Format ID tidak valid (bukan UUID)")
+	}
+
+	var pertanyaan model.Pertanyaan
+	if err := database.DB.Preload("Bobot", func(db *gorm.DB) *gorm.DB {
+		return db.Order("jurusan_id")
+	}).Preload("Skala.Opsi", model.PreloadOpsi).Where("id = ?", id).First(&pertanyaan).Error; err != nil {
+		return nil, fiber.NewError(404, "Pertanyaan tidak ditemukan")
+	}
+	return &pertanyaan, nil
+}
+
+// GET: Bobot pertanyaan terhadap setiap jurusan, beserta reverse dan skor pilihan
+// yang tidak ditampilkan ke siswa. Pertanyaan tanpa bobot dihitung penuh ke jurusan_id-nya.
+func GetBobot(c *fiber.Ctx) error {
+	pertanyaan, err := findPertanyaanBobot(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Berhasil mengambil bobot pertanyaan",
+		"data": fiber.Map{
+			"pertanyaan_id": pertanyaan.ID,
+			"default":       len(pertanyaan.Bobot) == 0,
+			"bobot":         pertanyaan.BobotEfektif(),
+			"reverse":       pertanyaan.Reverse,
+			"opsi":          opsiDenganSkor(pertanyaan.Skala.Opsi),
+		},
+	})
+}
+
+// PUT: Mengatur bobot pertanyaan untuk satu jurusan (boleh negatif)
+func SetBobot(c *fiber.Ctx) error {
+	type BobotInput struct {
+		Bobot *float64 `json:"bobot"`
+	}
+
+	pertanyaan, err := findPertanyaanBobot(c)
+	if err != nil {
+		return err
+	}
+
+	jurusanID, err := c.ParamsInt("jurusan_id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Format jurusan_id tidak valid",
+		})
+	}
+
+	var input BobotInput
+	if err := c.BodyParser(&input); err != nil || input.Bobot == nil {
+		return c.Status(400).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Bobot wajib diisi",
+		})
+	}
+
+	db := database.DB
+	var jurusan model.Jurusan
+	if err := db.First(&jurusan, jurusanID).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Jurusan tidak ditemukan",
+		})
+	}
+
+	bobot := model.BobotJurusan{
+		PertanyaanID: pertanyaan.ID,
+		JurusanID:    jurusan.ID,
+		Bobot:        *input.Bobot,
+	}
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "pertanyaan_id"}, {Name: "jurusan_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"bobot", "updated_at"}),
+	}).Create(&bobot).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Gagal menyimpan bobot",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Bobot berhasil disimpan",
+		"data":    bobot,
+	})
+}
+
+// DELETE: Menghapus bobot pertanyaan untuk satu jurusan
+func DeleteBobot(c *fiber.Ctx) error {
+	pertanyaan, err := findPertanyaanBobot(c)
+	if err != nil {
+		return err
+	}
+
+	jurusanID, err := c.ParamsInt("jurusan_id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Format jurusan_id tidak valid",
+		})
+	}
+
+	result := database.DB.Where("pertanyaan_id = ? AND jurusan_id = ?", pertanyaan.ID, jurusanID).Delete(&model.BobotJurusan{})
+	if result.Error != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Gagal menghapus bobot",
+			"error":   result.Error.Error(),
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Bobot tidak ditemukan",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Bobot berhasil dihapus",
+	})
+}
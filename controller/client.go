@@ -0,0 +1,312 @@
+package controller
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"jalurku/database"
+	"jalurku/model"
+	"jalurku/token"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ============================================
+// CLIENT & API KEY HANDLERS (ADMIN)
+// ============================================
+
+// Scope yang bisa diberikan ke API key, sesuai grup rute di /api
+var apiKeyScopes = map[string]bool{
+	"*":          true,
+	"auth":       true,
+	"user":       true,
+	"angket":     true,
+	"pertanyaan": true,
+	"hasil":      true,
+	"admin":      true,
+}
+
+// Membuat API key baru untuk klien, key mentah hanya dikembalikan sekali
+func newAPIKey(clientID uuid.UUID, scopes string, expiresAt *time.Time) (*model.APIKey, string, error) {
+	secret, err := token.Random()
+	if err != nil {
+		return nil, "", err
+	}
+	raw := "jk_" + secret
+
+	key := model.APIKey{
+		ClientID:  clientID,
+		Prefix:    raw[:11],
+		KeyHash:   token.Hash(raw),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := database.DB.Create(&key).Error; err != nil {
+		return nil, "", err
+	}
+	return &key, raw, nil
+}
+
+// Validasi dan normalisasi daftar scope
+func normalizeScopes(scopes []string) (string, bool) {
+	var result []string
+	for _, s := range scopes {
+		s = strings.TrimSpace(s)
+		if !apiKeyScopes[s] {
+			return "", false
+		}
+		result = append(result, s)
+	}
+	if len(result) == 0 {
+		return "", false
+	}
+	return strings.Join(result, ","), true
+}
+
+// Mendaftarkan aplikasi klien baru
+func CreateClient(c *fiber.Ctx) error {
+	type ClientInput struct {
+		Name string `json:"name"`
+	}
+
+	input := new(ClientInput)
+	if err := c.BodyParser(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid JSON format",
+			"data":    err.Error(),
+		})
+	}
+
+	if input.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Name is required",
+			"data":    nil,
+		})
+	}
+
+	client := model.Client{Name: input.Name}
+	if err := database.DB.Create(&client).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't create client",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Client created successfully",
+		"data":    client,
+	})
+}
+
+// Daftar semua klien beserta API key-nya (tanpa hash)
+func GetClients(c *fiber.Ctx) error {
+	var clients []model.Client
+	if err := database.DB.Preload("APIKeys").Order("created_at").Find(&clients).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Database error",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Clients found",
+		"data":    clients,
+	})
+}
+
+// Membuat API key baru untuk klien
+func CreateAPIKey(c *fiber.Ctx) error {
+	type APIKeyInput struct {
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	clientID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid client ID",
+			"data":    nil,
+		})
+	}
+
+	input := new(APIKeyInput)
+	if err := c.BodyParser(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid JSON format",
+			"data":    err.Error(),
+		})
+	}
+
+	scopes, ok := normalizeScopes(input.Scopes)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid scopes",
+			"data":    nil,
+		})
+	}
+
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Expiry must be in the future",
+			"data":    nil,
+		})
+	}
+
+	var client model.Client
+	if err := database.DB.First(&client, "id = ?", clientID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Client not found",
+			"data":    nil,
+		})
+	}
+
+	key, raw, err := newAPIKey(client.ID, scopes, input.ExpiresAt)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't create API key",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "API key created, store it now because it won't be shown again",
+		"data": fiber.Map{
+			"key":     raw,
+			"api_key": key,
+		},
+	})
+}
+
+// Mengganti API key dengan key baru (scope dan masa berlaku sama).
+// Key lama tetap berlaku selama grace_period (default langsung dicabut).
+func RotateAPIKey(c *fiber.Ctx) error {
+	type RotateInput struct {
+		GracePeriod string `json:"grace_period"`
+	}
+
+	input := new(RotateInput)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Invalid JSON format",
+				"data":    err.Error(),
+			})
+		}
+	}
+
+	var grace time.Duration
+	if input.GracePeriod != "" {
+		d, err := time.ParseDuration(input.GracePeriod)
+		if err != nil || d < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Invalid grace period",
+				"data":    nil,
+			})
+		}
+		grace = d
+	}
+
+	old, err := findActiveAPIKey(c.Params("id"))
+	if err != nil {
+		return apiKeyNotFound(c, err)
+	}
+
+	key, raw, err := newAPIKey(old.ClientID, old.Scopes, old.ExpiresAt)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't create API key",
+			"data":    err.Error(),
+		})
+	}
+
+	revokedAt := time.Now().Add(grace)
+	if err := database.DB.Model(old).Update("revoked_at", revokedAt).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't revoke old API key",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "API key rotated, store it now because it won't be shown again",
+		"data": fiber.Map{
+			"key":     raw,
+			"api_key": key,
+		},
+	})
+}
+
+// Mencabut API key
+func RevokeAPIKey(c *fiber.Ctx) error {
+	key, err := findActiveAPIKey(c.Params("id"))
+	if err != nil {
+		return apiKeyNotFound(c, err)
+	}
+
+	if err := database.DB.Model(key).Update("revoked_at", time.Now()).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't revoke API key",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "API key revoked",
+		"data":    nil,
+	})
+}
+
+// Dapatkan API key yang belum dicabut dari id
+func findActiveAPIKey(id string) (*model.APIKey, error) {
+	keyID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var key model.APIKey
+	if err := database.DB.First(&key, "id = ?", keyID).Error; err != nil {
+		return nil, err
+	}
+	if !key.Active(time.Now()) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &key, nil
+}
+
+func apiKeyNotFound(c *fiber.Ctx, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "API key not found or already revoked",
+			"data":    nil,
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"status":  "error",
+		"message": "Database error",
+		"data":    err.Error(),
+	})
+}
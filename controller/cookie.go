@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"strings"
+	"time"
+
+	"jalurku/config"
+	"jalurku/middleware"
+	"jalurku/token"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ============================================
+// COOKIE SESSION HELPERS
+// ============================================
+
+// Klien browser meminta sesi cookie dengan header X-Auth-Mode: cookie
+func wantsCookie(c *fiber.Ctx) bool {
+	return strings.EqualFold(c.Get("X-Auth-Mode"), "cookie")
+}
+
+func authCookie(name, value, path string, maxAge time.Duration, httpOnly bool) *fiber.Cookie {
+	return &fiber.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   config.Config("COOKIE_DOMAIN"),
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   config.BoolWithDefault("COOKIE_SECURE", config.IsProduction()),
+		HTTPOnly: httpOnly,
+		SameSite: config.ConfigWithDefault("COOKIE_SAMESITE", fiber.CookieSameSiteLaxMode),
+	}
+}
+
+// Menyimpan token di cookie HttpOnly jika klien memakai sesi cookie.
+// Token dihapus dari response body, dan CSRF token baru dikembalikan.
+func respondTokens(c *fiber.Ctx, tokens fiber.Map, cookie bool) (fiber.Map, error) {
+	if !cookie {
+		return tokens, nil
+	}
+
+	csrf, err := token.Random()
+	if err != nil {
+		return nil, err
+	}
+
+	access, _ := tokens["token"].(string)
+	refresh, _ := tokens["refresh_token"].(string)
+
+	c.Cookie(authCookie(middleware.CookieToken, access, "/", token.AccessTTL(), true))
+	c.Cookie(authCookie(middleware.CookieRefresh, refresh, "/api/auth", token.RefreshTTL(), true))
+	c.Cookie(authCookie(middleware.CookieCSRF, csrf, "/", token.RefreshTTL(), false))
+
+	delete(tokens, "token")
+	delete(tokens, "refresh_token")
+	tokens["csrf_token"] = csrf
+	return tokens, nil
+}
+
+// Menghapus cookie sesi (saat log keluar)
+func clearAuthCookies(c *fiber.Ctx) {
+	for _, cookie := range []*fiber.Cookie{
+		authCookie(middleware.CookieToken, "", "/", 0, true),
+		authCookie(middleware.CookieRefresh, "", "/api/auth", 0, true),
+		authCookie(middleware.CookieCSRF, "", "/", 0, false),
+	} {
+		cookie.Expires = time.Unix(1, 0)
+		c.Cookie(cookie)
+	}
+}
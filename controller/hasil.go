@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"time"
+
+	"jalurku/database"
+	"jalurku/model"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ============================================
+// HASIL ANGKET HANDLERS (GURU BK, WALI KELAS, ADMIN)
+// ============================================
+
+// Parameter halaman dari query ?page=&limit= (limit maksimal 100)
+func pageParams(c *fiber.Ctx) (page, limit int) {
+	page = c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit = c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return page, limit
+}
+
+// Daftar hasil angket siswa, bisa difilter dengan ?user_id= dan ?jurusan_id=
+func GetHasilAngket(c *fiber.Ctx) error {
+	type HasilData struct {
+		ID        uuid.UUID `json:"id"`
+		UserID    uuid.UUID `json:"user_id"`
+		Name      string    `json:"name"`
+		Email     string    `json:"email"`
+		JurusanID int       `json:"jurusan_id"`
+		Jurusan   string    `json:"jurusan"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	page, limit := pageParams(c)
+
+	query := database.DB.Model(&model.HasilAngket{}).
+		Joins("JOIN users ON users.id = hasil_angket.user_id AND users.deleted_at IS NULL").
+		Joins("JOIN jurusan ON jurusan.id = hasil_angket.jurusan_id")
+
+	if userID := c.Query("user_id"); userID != "" {
+		if _, err := uuid.Parse(userID); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Invalid user ID",
+				"data":    nil,
+			})
+		}
+		query = query.Where("hasil_angket.user_id = ?", userID)
+	}
+	if jurusanID := c.QueryInt("jurusan_id"); jurusanID > 0 {
+		query = query.Where("hasil_angket.jurusan_id = ?", jurusanID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Database error",
+			"data":    err.Error(),
+		})
+	}
+
+	results := []HasilData{}
+	if err := query.
+		Select("hasil_angket.id, hasil_angket.user_id, users.name, users.email, hasil_angket.jurusan_id, jurusan.name AS jurusan, hasil_angket.created_at").
+		Order("hasil_angket.created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Scan(&results).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Database error",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Hasil angket found",
+		"data": fiber.Map{
+			"items": results,
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"jalurku/database"
+	"jalurku/model"
+	"jalurku/token"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// ============================================
+// IMPERSONATION HANDLERS (SUPERADMIN)
+// ============================================
+
+// Membuat token untuk melihat API sebagai pengguna lain.
+// Token hanya bisa membaca, berlaku selama IMPERSONATION_EXPIRE, dan setiap request dicatat di audit log.
+func Impersonate(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Token).Claims.(jwt.MapClaims)
+	actorID, _ := claims["user_id"].(string)
+	sid, _ := claims["sid"].(string)
+
+	user, err := adminFindUser(c, false)
+	if err != nil {
+		return err
+	}
+
+	if user.ID.String() == actorID || privilegedRole(user.Role) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "This account can't be impersonated",
+			"data":    nil,
+		})
+	}
+
+	t, err := token.NewImpersonationToken(user, actorID, sid)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not generate token",
+			"data":    nil,
+		})
+	}
+
+	impersonatorID, _ := uuid.Parse(actorID)
+	entry := model.AuditLog{
+		ImpersonatorID: impersonatorID,
+		UserID:         user.ID,
+		Method:         c.Method(),
+		Path:           c.OriginalURL(),
+		Status:         fiber.StatusOK,
+		IP:             c.IP(),
+	}
+	if err := database.DB.Create(&entry).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't write audit log",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Impersonation token created",
+		"data": fiber.Map{
+			"token":      t,
+			"expires_in": int(token.ImpersonationTTL().Seconds()),
+			"user":       newAdminUserData(user),
+		},
+	})
+}
+
+// Daftar audit log impersonasi, bisa difilter dengan ?impersonator_id= dan ?user_id=
+func GetAuditLogs(c *fiber.Ctx) error {
+	page, limit := pageParams(c)
+
+	query := database.DB.Model(&model.AuditLog{})
+	for _, field := range []string{"impersonator_id", "user_id"} {
+		if v := c.Query(field); v != "" {
+			if _, err := uuid.Parse(v); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"status":  "error",
+					"message": "Invalid " + field,
+					"data":    nil,
+				})
+			}
+			query = query.Where(field+" = ?", v)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Database error",
+			"data":    err.Error(),
+		})
+	}
+
+	logs := []model.AuditLog{}
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&logs).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Database error",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Audit logs found",
+		"data": fiber.Map{
+			"items": logs,
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
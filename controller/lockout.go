@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"jalurku/config"
+	"jalurku/database"
+	"jalurku/model"
+)
+
+// ============================================
+// LOGIN LOCKOUT HELPERS
+// ============================================
+
+// Key Redis untuk percobaan log masuk yang gagal.
+// Dicatat per akun dan per IP. Akun yang ditemukan dihitung dari ID-nya, sehingga
+// email dan username pengguna yang sama berbagi satu hitungan. Identitas yang tidak
+// terdaftar dihitung dari identitas yang diketik, dengan jeda dan kunci yang sama
+// agar tidak membocorkan akun yang terdaftar.
+//
+//	login_fail:<scope>:<value>  -> jumlah kegagalan dalam jendela waktu
+//	login_wait:<scope>:<value>  -> ada selama percobaan berikutnya harus menunggu
+const (
+	loginFailPrefix = "login_fail:"
+	loginWaitPrefix = "login_wait:"
+)
+
+type loginPolicy struct {
+	Free      int64         // kegagalan tanpa jeda
+	Threshold int64         // kegagalan sebelum dikunci
+	Window    time.Duration // jendela penghitungan kegagalan
+	MaxDelay  time.Duration // jeda maksimal sebelum dikunci
+	Lock      time.Duration // lama penguncian
+}
+
+func identityLoginPolicy() loginPolicy {
+	return loginPolicy{
+		Free:      int64(config.IntWithDefault("LOGIN_BACKOFF_FREE", 3)),
+		Threshold: int64(config.IntWithDefault("LOGIN_LOCK_THRESHOLD", 10)),
+		Window:    config.DurationWithDefault("LOGIN_FAIL_WINDOW", 15*time.Minute),
+		MaxDelay:  config.DurationWithDefault("LOGIN_BACKOFF_MAX", time.Minute),
+		Lock:      config.DurationWithDefault("LOGIN_LOCK_DURATION", 15*time.Minute),
+	}
+}
+
+func ipLoginPolicy() loginPolicy {
+	p := identityLoginPolicy()
+	p.Free = int64(config.IntWithDefault("LOGIN_IP_BACKOFF_FREE", 10))
+	p.Threshold = int64(config.IntWithDefault("LOGIN_IP_LOCK_THRESHOLD", 50))
+	return p
+}
+
+// Normalisasi identitas sama seperti saat mencari pengguna, agar "Budi@x.id" dan
+// "budi@x.id " atau "budi#" dan "budi" dihitung sebagai identitas yang sama
+func normalizeIdentity(identity string) string {
+	if isEmail(identity) {
+		return model.NormalizeEmail(identity)
+	}
+	return model.NormalizeUsername(identity)
+}
+
+// Subjek hitungan kegagalan: ID pengguna jika identitas ditemukan,
+// selain itu identitas yang diketik (setelah dinormalisasi)
+func loginSubject(identity string, user *model.User) string {
+	if user != nil {
+		return "user:" + user.ID.String()
+	}
+	return "id:" + normalizeIdentity(identity)
+}
+
+// Berapa lama lagi log masuk harus ditunda untuk subjek atau IP ini?
+func loginRetryAfter(subject, ip string) time.Duration {
+	ctx := context.Background()
+	rdb := database.RedisClient
+
+	var wait time.Duration
+	for _, key := range []string{
+		loginWaitPrefix + subject,
+		loginWaitPrefix + "ip:" + ip,
+	} {
+		ttl, err := rdb.PTTL(ctx, key).Result()
+		if err == nil && ttl > wait {
+			wait = ttl
+		}
+	}
+	return wait
+}
+
+// Mencatat kegagalan log masuk dan menentukan jeda atau penguncian berikutnya
+func registerLoginFailure(subject, ip string) {
+	recordFailure(subject, identityLoginPolicy())
+	recordFailure("ip:"+ip, ipLoginPolicy())
+}
+
+func recordFailure(subject string, p loginPolicy) {
+	ctx := context.Background()
+	rdb := database.RedisClient
+	failKey := loginFailPrefix + subject
+
+	n, err := rdb.Incr(ctx, failKey).Result()
+	if err != nil {
+		return
+	}
+	if n == 1 {
+		rdb.Expire(ctx, failKey, p.Window)
+	}
+
+	var wait time.Duration
+	switch {
+	case n >= p.Threshold:
+		// Dikunci, hitungan dimulai lagi setelah kunci habis
+		wait = p.Lock
+		rdb.Del(ctx, failKey)
+	case n > p.Free:
+		// Jeda bertambah dua kali lipat setiap kegagalan
+		wait = time.Second << min(n-p.Free-1, 30)
+		if wait > p.MaxDelay {
+			wait = p.MaxDelay
+		}
+	default:
+		return
+	}
+
+	rdb.Set(ctx, loginWaitPrefix+subject, 1, wait)
+}
+
+// Menghapus catatan kegagalan setelah log masuk berhasil
+func clearLoginFailures(subject string) {
+	database.RedisClient.Del(context.Background(), loginFailPrefix+subject, loginWaitPrefix+subject)
+}
+
+// Menghapus catatan kegagalan dan jeda untuk satu IP
+func clearIPLoginFailures(ip string) {
+	subject := "ip:" + ip
+	database.RedisClient.Del(context.Background(), loginFailPrefix+subject, loginWaitPrefix+subject)
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestNormalizeIdentityMatchesLookup(t *testing.T) {
+	tests := []struct {
+		identity, want string
+	}{
+		{" Budi@Sekolah.sch.id ", "budi@sekolah.sch.id"},
+		{"Budi.Santoso", "budi.santoso"},
+		{"budi#", "budi"},
+		{"#budi", "budi"},
+		{"budi$", "budi"},
+		{"Budi Santoso", "budi.santoso"},
+	}
+	for _, tt := range tests {
+		if got := normalizeIdentity(tt.identity); got != tt.want {
+			t.Errorf("normalizeIdentity(%q) = %q, want %q", tt.identity, got, tt.want)
+		}
+	}
+}
+
+func TestLoginFailuresSharedAcrossIdentities(t *testing.T) {
+	t.Setenv("LOGIN_BACKOFF_FREE", "1")
+	t.Cleanup(func() { clearIPLoginFailures("0.0.0.0") })
+
+	app := fiber.New()
+	app.Post("/login", Login)
+	user := createTestUser(t, testEmail("lockout"), true)
+
+	// Satu kegagalan lewat email dan satu lewat username dihitung untuk akun yang sama
+	for _, identity := range []string{user.Email, strings.ToUpper(user.Username)} {
+		status, res := doJSON(t, app, "POST", "/login", fiber.Map{"identity": identity, "password": "salah"})
+		if status != fiber.StatusUnauthorized {
+			t.Fatalf("%s: status %d %v, want 401", identity, status, res)
+		}
+	}
+
+	status, res := doJSON(t, app, "POST", "/login", fiber.Map{"identity": user.Email, "password": "rahasiaKu2025"})
+	if status != fiber.StatusTooManyRequests {
+		t.Fatalf("status %d %v, want 429", status, res)
+	}
+}
@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"jalurku/config"
+	"jalurku/database"
+	"jalurku/mailer"
+	"jalurku/model"
+	"jalurku/token"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ============================================
+// MAGIC LINK HANDLERS
+// ============================================
+
+// Key Redis untuk batas permintaan magic link per alamat email
+//
+//	magic_link_wait:<email>  -> jeda antar permintaan
+//	magic_link_count:<email> -> jumlah permintaan dalam satu jam
+const (
+	magicLinkWaitPrefix  = "magic_link_wait:"
+	magicLinkCountPrefix = "magic_link_count:"
+)
+
+// Apakah log masuk tanpa password diaktifkan?
+func magicLinkEnabled() bool {
+	return config.BoolWithDefault("MAGIC_LINK_ENABLED", false)
+}
+
+// Apakah alamat email masih boleh meminta magic link?
+// Batas: satu kali per MAGIC_LINK_COOLDOWN, dan MAGIC_LINK_MAX_PER_HOUR kali per jam.
+func allowMagicLink(email string) (bool, error) {
+	ctx := context.Background()
+	rdb := database.RedisClient
+
+	cooldown := config.DurationWithDefault("MAGIC_LINK_COOLDOWN", time.Minute)
+	ok, err := rdb.SetNX(ctx, magicLinkWaitPrefix+email, 1, cooldown).Result()
+	if err != nil || !ok {
+		return false, err
+	}
+
+	countKey := magicLinkCountPrefix + email
+	count, err := rdb.Incr(ctx, countKey).Result()
+	if err != nil {
+		return false, err
+	}
+	if count == 1 {
+		rdb.Expire(ctx, countKey, time.Hour)
+	}
+	return count <= int64(config.IntWithDefault("MAGIC_LINK_MAX_PER_HOUR", 5)), nil
+}
+
+// Membuat magic link dan mengirimkannya ke email pengguna
+func sendMagicLink(user *model.User) error {
+	ttl := config.DurationWithDefault("MAGIC_LINK_EXPIRE", 15*time.Minute)
+	raw, err := token.IssueOneTime(token.PurposeMagicLink, user.ID.String(), ttl)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/magic-link?token=%s",
+		strings.TrimRight(config.ConfigWithDefault("APP_URL", "http://localhost:3000"), "/"), raw)
+	body := fmt.Sprintf("Halo %s,\n\n"+
+		"Buka tautan berikut untuk masuk ke jalurku tanpa password (berlaku %s, hanya bisa dipakai sekali):\n\n%s\n\n"+
+		"Jika kamu tidak meminta tautan ini, abaikan email ini.",
+		user.Name, ttl, link)
+
+	mailer.SendAsync(user.Email, "Tautan masuk jalurku", body)
+	return nil
+}
+
+// Meminta tautan log masuk melalui email.
+// Response selalu sama agar tidak membocorkan email yang terdaftar.
+func RequestMagicLink(c *fiber.Ctx) error {
+	type MagicLinkInput struct {
+		Email string `json:"email"`
+	}
+
+	if !magicLinkEnabled() {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+
+	input := new(MagicLinkInput)
+	if err := c.BodyParser(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid JSON format",
+			"data":    err.Error(),
+		})
+	}
+
+	if !isEmail(input.Email) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid email format",
+			"data":    nil,
+		})
+	}
+
+	allowed, err := allowMagicLink(model.NormalizeEmail(input.Email))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal Server Error",
+			"data":    err.Error(),
+		})
+	}
+
+	if allowed {
+		user, err := getUserByEmail(input.Email)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Internal Server Error",
+				"data":    err.Error(),
+			})
+		}
+
+		if user != nil && loginBlocked(user) == "" {
+			if err := sendMagicLink(user); err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"status":  "error",
+					"message": "Couldn't create login link",
+					"data":    nil,
+				})
+			}
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "If the email is registered, a login link has been sent",
+		"data":    nil,
+	})
+}
+
+// Menukar magic link dengan token JWT, sama seperti log masuk biasa
+func RedeemMagicLink(c *fiber.Ctx) error {
+	type RedeemInput struct {
+		Token string `json:"token"`
+	}
+
+	if !magicLinkEnabled() {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+
+	input := new(RedeemInput)
+	if err := c.BodyParser(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid JSON format",
+			"data":    err.Error(),
+		})
+	}
+
+	if input.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Token is required",
+			"data":    nil,
+		})
+	}
+
+	userID, err := token.ConsumeOneTime(token.PurposeMagicLink, input.Token)
+	if errors.Is(err, token.ErrInvalidOneTime) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired login link",
+			"data":    nil,
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal Server Error",
+			"data":    err.Error(),
+		})
+	}
+
+	db := database.DB
+	var user model.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired login link",
+			"data":    nil,
+		})
+	}
+
+	// Membuka tautan dari email sekaligus membuktikan kepemilikan email
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		db.Model(&user).Update("email_verified_at", now)
+	}
+
+	if user.MFAEnabledAt != nil {
+		return mfaChallenge(c, &user)
+	}
+	return loginSuccess(c, &user, token.Grant{UserID: user.ID.String()})
+}
@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"jalurku/database"
+	"jalurku/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var magicLinkToken = regexp.MustCompile(`/magic-link\?token=(\S+)`)
+
+func magicLinkApp() *fiber.App {
+	app := fiber.New()
+	app.Post("/auth/magic-link", RequestMagicLink)
+	app.Post("/auth/magic-link/verify", RedeemMagicLink)
+	return app
+}
+
+// Meminta magic link dan mengambil token dari email yang terkirim
+func requestMagicLink(t *testing.T, app *fiber.App, email string) string {
+	t.Helper()
+
+	status, res := doJSON(t, app, "POST", "/auth/magic-link", fiber.Map{"email": email})
+	if status != fiber.StatusOK {
+		t.Fatalf("request magic link: status %d %v", status, res)
+	}
+
+	msg := waitMail(t, email)
+	m := magicLinkToken.FindStringSubmatch(msg.Body)
+	if m == nil {
+		t.Fatalf("email without magic link: %s", msg.Body)
+	}
+	return m[1]
+}
+
+func TestMagicLinkRedeemOnce(t *testing.T) {
+	app := magicLinkApp()
+	user := createTestUser(t, testEmail("magic"), false)
+
+	raw := requestMagicLink(t, app, user.Email)
+
+	status, res := doJSON(t, app, "POST", "/auth/magic-link/verify", fiber.Map{"token": raw})
+	if status != fiber.StatusOK {
+		t.Fatalf("redeem: status %d %v", status, res)
+	}
+	if tok, _ := responseData(t, res)["token"].(string); tok == "" {
+		t.Fatalf("redeem without access token: %v", res)
+	}
+
+	// Membuka tautan dari email membuktikan kepemilikan email
+	var updated model.User
+	database.DB.First(&updated, "id = ?", user.ID)
+	if updated.EmailVerifiedAt == nil {
+		t.Fatal("email not marked verified after magic link")
+	}
+
+	status, res = doJSON(t, app, "POST", "/auth/magic-link/verify", fiber.Map{"token": raw})
+	if status != fiber.StatusUnauthorized {
+		t.Fatalf("second redeem: status %d %v, want 401", status, res)
+	}
+}
+
+func TestMagicLinkExpired(t *testing.T) {
+	app := magicLinkApp()
+	user := createTestUser(t, testEmail("expired"), true)
+
+	raw := requestMagicLink(t, app, user.Email)
+	testRedis.FastForward(15*time.Minute + time.Second)
+
+	status, res := doJSON(t, app, "POST", "/auth/magic-link/verify", fiber.Map{"token": raw})
+	if status != fiber.StatusUnauthorized {
+		t.Fatalf("expired redeem: status %d %v, want 401", status, res)
+	}
+}
+
+func TestMagicLinkNewLinkReplacesOld(t *testing.T) {
+	app := magicLinkApp()
+	user := createTestUser(t, testEmail("replace"), true)
+
+	first := requestMagicLink(t, app, user.Email)
+	testRedis.FastForward(time.Minute + time.Second) // lewati jeda antar permintaan
+	second := requestMagicLink(t, app, user.Email)
+
+	if status, _ := doJSON(t, app, "POST", "/auth/magic-link/verify", fiber.Map{"token": first}); status != fiber.StatusUnauthorized {
+		t.Fatalf("old link: status %d, want 401", status)
+	}
+	if status, res := doJSON(t, app, "POST", "/auth/magic-link/verify", fiber.Map{"token": second}); status != fiber.StatusOK {
+		t.Fatalf("new link: status %d %v", status, res)
+	}
+}
+
+func TestMagicLinkUnknownEmail(t *testing.T) {
+	app := magicLinkApp()
+	email := testEmail("unknown")
+
+	// Response sama dengan email terdaftar, tapi tidak ada email yang dikirim
+	status, _ := doJSON(t, app, "POST", "/auth/magic-link", fiber.Map{"email": email})
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d, want 200", status)
+	}
+	expectNoMail(t, email)
+}
+
+func TestMagicLinkDisabled(t *testing.T) {
+	t.Setenv("MAGIC_LINK_ENABLED", "false")
+	app := magicLinkApp()
+
+	if status, _ := doJSON(t, app, "POST", "/auth/magic-link", fiber.Map{"email": testEmail("off")}); status != fiber.StatusNotFound {
+		t.Fatalf("request: status %d, want 404", status)
+	}
+	if status, _ := doJSON(t, app, "POST", "/auth/magic-link/verify", fiber.Map{"token": "x"}); status != fiber.StatusNotFound {
+		t.Fatalf("redeem: status %d, want 404", status)
+	}
+}
+
+func TestMagicLinkCooldown(t *testing.T) {
+	app := magicLinkApp()
+	user := createTestUser(t, testEmail("cooldown"), true)
+
+	requestMagicLink(t, app, user.Email)
+
+	// Permintaan kedua dalam jeda mendapat response yang sama, tapi tidak ada email
+	status, res := doJSON(t, app, "POST", "/auth/magic-link", fiber.Map{"email": user.Email})
+	if status != fiber.StatusOK {
+		t.Fatalf("second request: status %d %v, want 200", status, res)
+	}
+	expectNoMail(t, user.Email)
+
+	// Setelah jeda habis, email dikirim lagi
+	testRedis.FastForward(time.Minute + time.Second)
+	requestMagicLink(t, app, user.Email)
+}
+
+func TestMagicLinkHourlyCap(t *testing.T) {
+	t.Setenv("MAGIC_LINK_MAX_PER_HOUR", "2")
+	app := magicLinkApp()
+	user := createTestUser(t, testEmail("cap"), true)
+
+	for i := 0; i < 2; i++ {
+		requestMagicLink(t, app, user.Email)
+		testRedis.FastForward(time.Minute + time.Second) // lewati jeda antar permintaan
+	}
+
+	// Permintaan ketiga dalam satu jam tidak dikirim walaupun jeda sudah lewat
+	status, res := doJSON(t, app, "POST", "/auth/magic-link", fiber.Map{"email": user.Email})
+	if status != fiber.StatusOK {
+		t.Fatalf("third request: status %d %v, want 200", status, res)
+	}
+	expectNoMail(t, user.Email)
+
+	// Batas dihitung ulang setelah satu jam
+	testRedis.FastForward(time.Hour)
+	requestMagicLink(t, app, user.Email)
+}
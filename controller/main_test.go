@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"jalurku/database"
+	"jalurku/mailer"
+	"jalurku/model"
+	"jalurku/oidc/oidctest"
+	"jalurku/token"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Pengganti lokal untuk pengujian: Redis (miniredis), database SQLite di memori,
+// mailer di memori dan penyedia OIDC tiruan
+var (
+	testRedis  *miniredis.Miniredis
+	testMail   *mailer.MemoryMailer
+	testOIDC   *oidctest.Server
+	testClient = "jalurku"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(runTests(m))
+}
+
+func runTests(m *testing.M) int {
+	var err error
+
+	testOIDC, err = oidctest.NewServer(testClient)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer testOIDC.Close()
+
+	env := map[string]string{
+		"JWT_ALG":            "EdDSA",
+		"APP_URL":            "http://localhost:3000",
+		"MAGIC_LINK_ENABLED": "true",
+		"MAGIC_LINK_EXPIRE":  "15m",
+		"OIDC_PROVIDERS":     "mock,other",
+	}
+	for _, name := range []string{"MOCK", "OTHER"} {
+		env["OIDC_"+name+"_DISCOVERY_URL"] = testOIDC.DiscoveryURL()
+		env["OIDC_"+name+"_CLIENT_ID"] = testClient
+		env["OIDC_"+name+"_REDIRECT_URL"] = "http://localhost:5173/auth/callback/" + strings.ToLower(name)
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+	}
+
+	testRedis, err = miniredis.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer testRedis.Close()
+	database.RedisClient = redis.NewClient(&redis.Options{Addr: testRedis.Addr()})
+
+	database.DB, err = gorm.Open(sqlite.Open("file:controller_test?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	sqlDB, _ := database.DB.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := database.DB.AutoMigrate(
+		&model.User{},
+		&model.UserIdentity{},
+		&model.Role{},
+		&model.Permission{},
+		&model.SigningKey{},
+		&model.Jurusan{},
+		&model.Skala{},
+		&model.OpsiSkala{},
+		&model.Pertanyaan{},
+		&model.BobotJurusan{},
+	); err != nil {
+		log.Fatal(err)
+	}
+	model.SeedRoles(database.DB)
+
+	if err := token.InitKeys(); err != nil {
+		log.Fatal(err)
+	}
+
+	testMail = mailer.NewMemoryMailer()
+	mailer.Default = testMail
+
+	return m.Run()
+}
+
+// Membuat pengguna untuk pengujian
+func createTestUser(t *testing.T, email string, verified bool) *model.User {
+	t.Helper()
+
+	hash, err := hashPassword("rahasiaKu2025")
+	if err != nil {
+		t.Fatal(err)
+	}
+	username, err := model.UniqueUsername(database.DB, strings.Split(email, "@")[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &model.User{
+		Name:     "Test User",
+		Username: username,
+		Email:    model.NormalizeEmail(email),
+		Password: hash,
+		Role:     model.RoleSiswa,
+	}
+	if verified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	if err := database.DB.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// Mengirim request JSON ke app dan membaca response JSON
+func doJSON(t *testing.T, app *fiber.App, method, path string, body any) (int, map[string]any) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	res := map[string]any{}
+	json.NewDecoder(resp.Body).Decode(&res)
+	return resp.StatusCode, res
+}
+
+// Menunggu email berikutnya untuk alamat tertentu
+func waitMail(t *testing.T, to string) mailer.Message {
+	t.Helper()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case msg := <-testMail.Outbox:
+			if msg.To == to {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("no email sent to %s", to)
+		}
+	}
+}
+
+// Memastikan tidak ada email yang dikirim ke alamat tertentu
+func expectNoMail(t *testing.T, to string) {
+	t.Helper()
+
+	timeout := time.After(200 * time.Millisecond)
+	for {
+		select {
+		case msg := <-testMail.Outbox:
+			if msg.To == to {
+				t.Fatalf("unexpected email to %s: %s", to, msg.Subject)
+			}
+		case <-timeout:
+			return
+		}
+	}
+}
+
+// Nilai data dari response {"status","message","data"}
+func responseData(t *testing.T, res map[string]any) map[string]any {
+	t.Helper()
+	data, ok := res["data"].(map[string]any)
+	if !ok {
+		t.Fatalf("response without data: %v", res)
+	}
+	return data
+}
+
+// Alamat email unik per pengujian
+func testEmail(prefix string) string {
+	return fmt.Sprintf("%s.%d@sekolah.sch.id", prefix, time.Now().UnixNano())
+}
@@ -0,0 +1,443 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"jalurku/config"
+	"jalurku/database"
+	"jalurku/model"
+	"jalurku/token"
+	"jalurku/totp"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ============================================
+// MFA (TOTP) HANDLERS
+// ============================================
+
+// Key Redis untuk verifikasi dua langkah.
+//
+//	mfa_setup:<user_id>           -> secret yang sedang didaftarkan
+//	mfa_used:<user_id>:<counter>  -> kode TOTP yang sudah dipakai
+const (
+	mfaSetupPrefix = "mfa_setup:"
+	mfaUsedPrefix  = "mfa_used:"
+)
+
+const recoveryCodeCount = 10
+
+// Langkah pertama log masuk untuk pengguna dengan MFA, token MFA ditukar di /api/auth/mfa
+func mfaChallenge(c *fiber.Ctx, user *model.User) error {
+	ttl := config.DurationWithDefault("MFA_LOGIN_EXPIRE", 5*time.Minute)
+	mfaToken, err := token.IssueOneTime(token.PurposeMFALogin, user.ID.String(), ttl)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not generate token",
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "MFA code required",
+		"data": fiber.Map{
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+			"expires_in":   int(ttl.Seconds()),
+		},
+	})
+}
+
+// Memvalidasi kode TOTP pengguna, kode yang sama tidak bisa dipakai dua kali
+func verifyTOTP(userID uuid.UUID, secret, code string) bool {
+	counter, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return false
+	}
+
+	key := fmt.Sprintf("%s%s:%d", mfaUsedPrefix, userID, counter)
+	fresh, err := database.RedisClient.SetNX(context.Background(), key, 1, 3*totp.Period).Result()
+	return err == nil && fresh
+}
+
+// Memakai satu kode pemulihan milik pengguna
+func useRecoveryCode(userID uuid.UUID, code string) bool {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	result := database.DB.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, token.Hash(code)).
+		Update("used_at", time.Now())
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+// Membuat ulang kode pemulihan, kode lama tidak berlaku lagi
+func generateRecoveryCodes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]model.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := hex.EncodeToString(b)
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		records = append(records, model.RecoveryCode{UserID: userID, CodeHash: token.Hash(raw)})
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Langkah kedua log masuk: tukar token MFA dan kode TOTP (atau kode pemulihan) dengan JWT.
+// Token MFA hanya berlaku untuk satu percobaan.
+func LoginMFA(c *fiber.Ctx) error {
+	type MFAInput struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	input := new(MFAInput)
+	if err := c.BodyParser(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid JSON format",
+			"data":    err.Error(),
+		})
+	}
+
+	if input.MFAToken == "" || (input.Code == "" && input.RecoveryCode == "") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "MFA token and code are required",
+			"data":    nil,
+		})
+	}
+
+	userID, err := token.ConsumeOneTime(token.PurposeMFALogin, input.MFAToken)
+	if errors.Is(err, token.ErrInvalidOneTime) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired MFA token",
+			"data":    nil,
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal Server Error",
+			"data":    err.Error(),
+		})
+	}
+
+	db := database.DB
+	var user model.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil || user.MFAEnabledAt == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired MFA token",
+			"data":    nil,
+		})
+	}
+
+	var ok bool
+	if input.Code != "" {
+		ok = verifyTOTP(user.ID, user.TOTPSecret, input.Code)
+	} else {
+		ok = useRecoveryCode(user.ID, input.RecoveryCode)
+	}
+
+	if !ok {
+		registerLoginFailure(loginSubject("", &user), c.IP())
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid MFA code",
+			"data":    nil,
+		})
+	}
+
+	return loginSuccess(c, &user, token.Grant{UserID: user.ID.String(), MFA: true})
+}
+
+// Memulai pendaftaran TOTP, secret baru berlaku setelah dikonfirmasi di /mfa/enable
+func SetupMFA(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid user ID in token",
+			"data":    nil,
+		})
+	}
+
+	db := database.DB
+	var user model.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "User not found",
+			"data":    nil,
+		})
+	}
+
+	if user.MFAEnabledAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "MFA already enabled",
+			"data":    nil,
+		})
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't generate secret",
+			"data":    nil,
+		})
+	}
+
+	if err := database.RedisClient.Set(context.Background(), mfaSetupPrefix+user.ID.String(), secret, 10*time.Minute).Err(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal Server Error",
+			"data":    err.Error(),
+		})
+	}
+
+	issuer := config.ConfigWithDefault("MFA_ISSUER", "jalurku")
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Scan the QR code, then confirm with a code",
+		"data": fiber.Map{
+			"secret":           secret,
+			"provisioning_uri": totp.ProvisioningURI(issuer, user.Email, secret),
+		},
+	})
+}
+
+// Mengaktifkan TOTP setelah kode dari aplikasi authenticator benar
+func EnableMFA(c *fiber.Ctx) error {
+	type CodeInput struct {
+		Code string `json:"code"`
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid user ID in token",
+			"data":    nil,
+		})
+	}
+
+	input := new(CodeInput)
+	if err := c.BodyParser(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid JSON format",
+			"data":    err.Error(),
+		})
+	}
+
+	ctx := context.Background()
+	secret, err := database.RedisClient.Get(ctx, mfaSetupPrefix+userID.String()).Result()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "MFA setup not started or expired",
+			"data":    nil,
+		})
+	}
+
+	if !verifyTOTP(userID, secret, input.Code) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid MFA code",
+			"data":    nil,
+		})
+	}
+
+	var codes []string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.User{}).
+			Where("id = ? AND mfa_enabled_at IS NULL", userID).
+			Updates(map[string]interface{}{"totp_secret": secret, "mfa_enabled_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		codes, err = generateRecoveryCodes(tx, userID)
+		return err
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "MFA already enabled",
+			"data":    nil,
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't enable MFA",
+			"data":    err.Error(),
+		})
+	}
+
+	database.RedisClient.Del(ctx, mfaSetupPrefix+userID.String())
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "MFA enabled, store the recovery codes now because they won't be shown again",
+		"data": fiber.Map{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+// Membuat ulang kode pemulihan, butuh kode TOTP yang valid
+func RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	type CodeInput struct {
+		Code string `json:"code"`
+	}
+
+	user, err := currentMFAUser(c)
+	if err != nil {
+		return err
+	}
+
+	input := new(CodeInput)
+	if err := c.BodyParser(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid JSON format",
+			"data":    err.Error(),
+		})
+	}
+
+	if !verifyTOTP(user.ID, user.TOTPSecret, input.Code) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid MFA code",
+			"data":    nil,
+		})
+	}
+
+	var codes []string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		codes, err = generateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't generate recovery codes",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Recovery codes regenerated, store them now because they won't be shown again",
+		"data": fiber.Map{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+// Menonaktifkan TOTP, butuh password dan kode TOTP yang valid
+func DisableMFA(c *fiber.Ctx) error {
+	type DisableInput struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+
+	user, err := currentMFAUser(c)
+	if err != nil {
+		return err
+	}
+
+	input := new(DisableInput)
+	if err := c.BodyParser(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid JSON format",
+			"data":    err.Error(),
+		})
+	}
+
+	if !CheckPasswordHash(input.Password, user.Password) || !verifyTOTP(user.ID, user.TOTPSecret, input.Code) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid password or MFA code",
+			"data":    nil,
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{"totp_secret": "", "mfa_enabled_at": nil}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&model.RecoveryCode{}).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't disable MFA",
+			"data":    err.Error(),
+		})
+	}
+
+	// Token yang ada masih membawa claim mfa: true, jadi semua sesi harus log masuk ulang
+	if err := token.RevokeUser(user.ID.String()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't revoke existing sessions",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "MFA disabled",
+		"data":    nil,
+	})
+}
+
+// Dapatkan pengguna sekarang yang sudah mengaktifkan MFA.
+// Error berupa *fiber.Error yang dirender oleh error handler global.
+func currentMFAUser(c *fiber.Ctx) (*model.User, error) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid user ID in token")
+	}
+
+	var user model.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "User not found")
+	}
+
+	if user.MFAEnabledAt == nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "MFA not enabled")
+	}
+	return &user, nil
+}
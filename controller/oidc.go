@@ -0,0 +1,269 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"jalurku/database"
+	"jalurku/model"
+	"jalurku/oidc"
+	"jalurku/token"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// ============================================
+// OIDC SINGLE SIGN-ON HANDLERS
+// ============================================
+
+// Key Redis untuk state log masuk OIDC yang sedang berjalan
+const oidcStatePrefix = "oidc_state:"
+
+var (
+	errOIDCEmailUnverified = errors.New("email from identity provider is not verified")
+	errOIDCUserDeleted     = errors.New("linked user has been deleted")
+	errOIDCLocalUnverified = errors.New("local account email is not verified")
+)
+
+type oidcState struct {
+	Provider string `json:"provider"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// Memulai log masuk OIDC, klien diarahkan ke authorization_url.
+// Setelah log masuk, penyedia identitas mengarahkan kembali ke OIDC_<NAMA>_REDIRECT_URL
+// dengan code dan state, yang lalu dikirim klien ke endpoint callback.
+func OIDCStart(c *fiber.Ctx) error {
+	provider, err := oidc.Get(c.Params("provider"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Unknown identity provider",
+			"data":    nil,
+		})
+	}
+
+	var values [3]string
+	for i := range values {
+		if values[i], err = token.Random(); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Internal Server Error",
+				"data":    nil,
+			})
+		}
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	ctx := context.Background()
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		log.Printf("⚠️ OIDC %s tidak bisa dimuat: %v", provider.Name, err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Identity provider unavailable",
+			"data":    nil,
+		})
+	}
+
+	data, _ := json.Marshal(oidcState{Provider: provider.Name, Nonce: nonce, Verifier: verifier})
+	if err := database.RedisClient.Set(ctx, oidcStatePrefix+state, data, 10*time.Minute).Err(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal Server Error",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Redirect to the identity provider",
+		"data": fiber.Map{
+			"authorization_url": authURL,
+			"state":             state,
+		},
+	})
+}
+
+// Menyelesaikan log masuk OIDC: tukar code dengan ID token, tautkan atau buat pengguna,
+// lalu berikan JWT seperti log masuk biasa
+func OIDCCallback(c *fiber.Ctx) error {
+	type CallbackInput struct {
+		Code  string `json:"code"`
+		State string `json:"state"`
+	}
+
+	input := new(CallbackInput)
+	if err := c.BodyParser(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid JSON format",
+			"data":    err.Error(),
+		})
+	}
+
+	if input.Code == "" || input.State == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Code and state are required",
+			"data":    nil,
+		})
+	}
+
+	provider, err := oidc.Get(c.Params("provider"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Unknown identity provider",
+			"data":    nil,
+		})
+	}
+
+	// State hanya bisa dipakai sekali
+	ctx := context.Background()
+	data, err := database.RedisClient.GetDel(ctx, oidcStatePrefix+input.State).Result()
+	var state oidcState
+	if err != nil || json.Unmarshal([]byte(data), &state) != nil || state.Provider != provider.Name {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired state",
+			"data":    nil,
+		})
+	}
+
+	identity, err := provider.Exchange(ctx, input.Code, state.Verifier, state.Nonce)
+	if err != nil {
+		log.Printf("⚠️ OIDC %s gagal: %v", provider.Name, err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Identity provider login failed",
+			"data":    nil,
+		})
+	}
+
+	user, err := findOrCreateOIDCUser(provider.Name, identity)
+	if errors.Is(err, errOIDCEmailUnverified) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Email from identity provider is not verified",
+			"data":    nil,
+		})
+	}
+	if errors.Is(err, errOIDCLocalUnverified) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "An account with this email exists but is not verified. Verify the email first, then sign in with the identity provider",
+			"data":    nil,
+		})
+	}
+	if errors.Is(err, errOIDCUserDeleted) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Account not found",
+			"data":    nil,
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't sign in user",
+			"data":    err.Error(),
+		})
+	}
+
+	if user.MFAEnabledAt != nil {
+		return mfaChallenge(c, user)
+	}
+	return loginSuccess(c, user, token.Grant{UserID: user.ID.String()})
+}
+
+// Cari pengguna dari akun penyedia identitas, atau tautkan dari email yang sudah
+// diverifikasi penyedia, atau buat pengguna baru
+func findOrCreateOIDCUser(provider string, id *oidc.Identity) (*model.User, error) {
+	db := database.DB
+
+	var link model.UserIdentity
+	err := db.Where("provider = ? AND subject = ?", provider, id.Subject).First(&link).Error
+	if err == nil {
+		// Pengguna yang sudah dihapus (soft delete) tidak bisa log masuk lewat akun tertaut
+		var user model.User
+		if err := db.First(&user, "id = ?", link.UserID).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errOIDCUserDeleted
+		} else if err != nil {
+			return nil, err
+		}
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if id.Email == "" || !id.EmailVerified {
+		return nil, errOIDCEmailUnverified
+	}
+
+	user, err := getUserByEmail(id.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	// Akun lokal yang emailnya belum diverifikasi bisa saja didaftarkan orang lain
+	// (lengkap dengan password-nya), jadi tidak boleh ditautkan otomatis
+	if user != nil && user.EmailVerifiedAt == nil {
+		return nil, errOIDCLocalUnverified
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		if user == nil {
+			// Password acak, pengguna SSO bisa membuat password lewat reset password
+			random, err := token.Random()
+			if err != nil {
+				return err
+			}
+			hash, err := hashPassword(random)
+			if err != nil {
+				return err
+			}
+
+			name := id.Name
+			if name == "" {
+				name = strings.Split(id.Email, "@")[0]
+			}
+			username, err := model.UniqueUsername(tx, strings.Split(id.Email, "@")[0])
+			if err != nil {
+				return err
+			}
+
+			user = &model.User{
+				Name:            name,
+				Username:        username,
+				Email:           model.NormalizeEmail(id.Email),
+				Password:        hash,
+				Role:            model.RoleSiswa,
+				EmailVerifiedAt: &now,
+			}
+			if err := tx.Create(user).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(&model.UserIdentity{
+			UserID:   user.ID,
+			Provider: provider,
+			Subject:  id.Subject,
+			Email:    id.Email,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"net/url"
+	"testing"
+
+	"jalurku/database"
+	"jalurku/model"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func oidcApp() *fiber.App {
+	app := fiber.New()
+	app.Get("/auth/oidc/:provider", OIDCStart)
+	app.Post("/auth/oidc/:provider/callback", OIDCCallback)
+	return app
+}
+
+// Memulai log masuk OIDC dan mensimulasikan persetujuan pengguna di penyedia tiruan.
+// Mengembalikan state dan authorization code untuk dikirim ke callback.
+func oidcAuthorize(t *testing.T, app *fiber.App, provider string, claims jwt.MapClaims) (state, code string) {
+	t.Helper()
+
+	status, res := doJSON(t, app, "GET", "/auth/oidc/"+provider, nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("start: status %d %v", status, res)
+	}
+	data := responseData(t, res)
+
+	u, err := url.Parse(data["authorization_url"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Fatalf("authorization URL without PKCE: %s", u)
+	}
+
+	claims["nonce"] = q.Get("nonce")
+	return data["state"].(string), testOIDC.Authorize(q.Get("code_challenge"), claims)
+}
+
+func oidcCallback(t *testing.T, app *fiber.App, provider, state, code string) (int, map[string]any) {
+	t.Helper()
+	return doJSON(t, app, "POST", "/auth/oidc/"+provider+"/callback", fiber.Map{"code": code, "state": state})
+}
+
+func oidcClaims(subject, email string, verified bool) jwt.MapClaims {
+	claims := testOIDC.Claims(subject)
+	if email != "" {
+		claims["email"] = email
+		claims["email_verified"] = verified
+	}
+	claims["name"] = "Siswa SSO"
+	return claims
+}
+
+func TestOIDCCallbackState(t *testing.T) {
+	app := oidcApp()
+	claims := oidcClaims("state-user", testEmail("state"), true)
+
+	// State yang tidak pernah dibuat
+	_, code := oidcAuthorize(t, app, "mock", claims)
+	if status, res := oidcCallback(t, app, "mock", "unknown-state", code); status != fiber.StatusBadRequest {
+		t.Fatalf("unknown state: status %d %v, want 400", status, res)
+	}
+
+	// State milik penyedia lain
+	state, code := oidcAuthorize(t, app, "mock", claims)
+	if status, res := oidcCallback(t, app, "other", state, code); status != fiber.StatusBadRequest {
+		t.Fatalf("state from other provider: status %d %v, want 400", status, res)
+	}
+
+	// State hanya bisa dipakai sekali
+	state, code = oidcAuthorize(t, app, "mock", claims)
+	if status, res := oidcCallback(t, app, "mock", state, code); status != fiber.StatusOK {
+		t.Fatalf("login: status %d %v", status, res)
+	}
+	if status, res := oidcCallback(t, app, "mock", state, code); status != fiber.StatusBadRequest {
+		t.Fatalf("reused state: status %d %v, want 400", status, res)
+	}
+}
+
+func TestOIDCCallbackRejectsNonceMismatch(t *testing.T) {
+	app := oidcApp()
+
+	claims := oidcClaims("nonce-user", testEmail("nonce"), true)
+	state, code := oidcAuthorize(t, app, "mock", claims)
+	claims["nonce"] = "other-nonce"
+
+	if status, res := oidcCallback(t, app, "mock", state, code); status != fiber.StatusUnauthorized {
+		t.Fatalf("status %d %v, want 401", status, res)
+	}
+}
+
+func TestOIDCLinksVerifiedEmailOnly(t *testing.T) {
+	app := oidcApp()
+	existing := createTestUser(t, testEmail("siti"), true)
+	subject := "sub-" + existing.ID.String()
+
+	// Email yang belum diverifikasi penyedia tidak boleh ditautkan ke akun yang ada
+	state, code := oidcAuthorize(t, app, "mock", oidcClaims(subject, existing.Email, false))
+	if status, res := oidcCallback(t, app, "mock", state, code); status != fiber.StatusForbidden {
+		t.Fatalf("unverified email: status %d %v, want 403", status, res)
+	}
+	var count int64
+	database.DB.Model(&model.UserIdentity{}).Where("subject = ?", subject).Count(&count)
+	if count != 0 {
+		t.Fatal("identity linked from unverified email")
+	}
+
+	// Email terverifikasi ditautkan ke akun yang ada, tanpa membuat pengguna baru
+	state, code = oidcAuthorize(t, app, "mock", oidcClaims(subject, existing.Email, true))
+	status, res := oidcCallback(t, app, "mock", state, code)
+	if status != fiber.StatusOK {
+		t.Fatalf("verified email: status %d %v", status, res)
+	}
+	if id := responseData(t, res)["user"].(map[string]any)["id"]; id != existing.ID.String() {
+		t.Fatalf("logged in as %v, want existing user %s", id, existing.ID)
+	}
+
+	var link model.UserIdentity
+	if err := database.DB.Where("provider = ? AND subject = ?", "mock", subject).First(&link).Error; err != nil {
+		t.Fatal(err)
+	}
+	if link.UserID != existing.ID {
+		t.Fatalf("identity linked to %s, want %s", link.UserID, existing.ID)
+	}
+	database.DB.Model(&model.User{}).Where("LOWER(email) = ?", existing.Email).Count(&count)
+	if count != 1 {
+		t.Fatalf("%d users with email %s, want 1", count, existing.Email)
+	}
+
+	// Akun yang sudah tertaut dicari dari subject, email tidak diperiksa lagi
+	state, code = oidcAuthorize(t, app, "mock", oidcClaims(subject, testEmail("changed"), false))
+	status, res = oidcCallback(t, app, "mock", state, code)
+	if status != fiber.StatusOK {
+		t.Fatalf("linked subject: status %d %v", status, res)
+	}
+	if id := responseData(t, res)["user"].(map[string]any)["id"]; id != existing.ID.String() {
+		t.Fatalf("linked subject logged in as %v, want %s", id, existing.ID)
+	}
+}
+
+func TestOIDCRefusesUnverifiedLocalAccount(t *testing.T) {
+	app := oidcApp()
+
+	// Akun yang didaftarkan dengan email korban tapi tidak pernah diverifikasi
+	squatter := createTestUser(t, testEmail("korban"), false)
+	subject := "sub-" + squatter.ID.String()
+
+	state, code := oidcAuthorize(t, app, "mock", oidcClaims(subject, squatter.Email, true))
+	if status, res := oidcCallback(t, app, "mock", state, code); status != fiber.StatusConflict {
+		t.Fatalf("status %d %v, want 409", status, res)
+	}
+
+	var count int64
+	database.DB.Model(&model.UserIdentity{}).Where("subject = ?", subject).Count(&count)
+	if count != 0 {
+		t.Fatal("identity linked to unverified local account")
+	}
+	var updated model.User
+	database.DB.First(&updated, "id = ?", squatter.ID)
+	if updated.EmailVerifiedAt != nil {
+		t.Fatal("unverified local account marked verified")
+	}
+}
+
+func TestOIDCCreatesUserFromVerifiedEmail(t *testing.T) {
+	app := oidcApp()
+	email := testEmail("baru")
+
+	state, code := oidcAuthorize(t, app, "mock", oidcClaims("sub-"+email, email, true))
+	status, res := oidcCallback(t, app, "mock", state, code)
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d %v", status, res)
+	}
+
+	var user model.User
+	if err := database.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != model.RoleSiswa || user.EmailVerifiedAt == nil || user.Username == "" {
+		t.Fatalf("unexpected new user %+v", user)
+	}
+}
+
+func TestOIDCRejectsMissingEmail(t *testing.T) {
+	app := oidcApp()
+
+	state, code := oidcAuthorize(t, app, "mock", oidcClaims("sub-no-email", "", false))
+	if status, res := oidcCallback(t, app, "mock", state, code); status != fiber.StatusForbidden {
+		t.Fatalf("status %d %v, want 403", status, res)
+	}
+}
+
+func TestOIDCRejectsDeletedLinkedUser(t *testing.T) {
+	app := oidcApp()
+	user := createTestUser(t, testEmail("hapus"), true)
+	subject := "sub-" + user.ID.String()
+
+	state, code := oidcAuthorize(t, app, "mock", oidcClaims(subject, user.Email, true))
+	if status, res := oidcCallback(t, app, "mock", state, code); status != fiber.StatusOK {
+		t.Fatalf("link: status %d %v", status, res)
+	}
+
+	if err := database.DB.Delete(user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	state, code = oidcAuthorize(t, app, "mock", oidcClaims(subject, user.Email, true))
+	if status, res := oidcCallback(t, app, "mock", state, code); status != fiber.StatusForbidden {
+		t.Fatalf("deleted user: status %d %v, want 403", status, res)
+	}
+}
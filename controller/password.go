@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"jalurku/config"
+	"jalurku/database"
+	"jalurku/mailer"
+	"jalurku/model"
+	"jalurku/password"
+	"jalurku/token"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ============================================
+// PASSWORD RESET HANDLERS
+// ============================================
+
+// Meminta tautan reset password melalui email.
+// Response selalu sama agar tidak membocorkan email yang terdaftar.
+func ForgotPassword(c *fiber.Ctx) error {
+	type ForgotInput struct {
+		Email string `json:"email"`
+	}
+
+	input := new(ForgotInput)
+	if err := c.BodyParser(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid JSON format",
+			"data":    err.Error(),
+		})
+	}
+
+	if !isEmail(input.Email) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid email format",
+			"data":    nil,
+		})
+	}
+
+	user, err := getUserByEmail(input.Email)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal Server Error",
+			"data":    err.Error(),
+		})
+	}
+
+	if user != nil {
+		if err := sendPasswordReset(user); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Couldn't create reset token",
+				"data":    nil,
+			})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "If the email is registered, a reset link has been sent",
+		"data":    nil,
+	})
+}
+
+// Membuat token reset dan mengirimkannya ke email pengguna
+func sendPasswordReset(user *model.User) error {
+	ttl := config.DurationWithDefault("PASSWORD_RESET_EXPIRE", time.Hour)
+	raw, err := token.IssueOneTime(token.PurposePasswordReset, user.ID.String(), ttl)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s",
+		strings.TrimRight(config.ConfigWithDefault("APP_URL", "http://localhost:3000"), "/"), raw)
+	body := fmt.Sprintf("Halo %s,\n\n"+
+		"Kami menerima permintaan untuk mengatur ulang password akun jalurku kamu.\n"+
+		"Buka tautan berikut untuk membuat password baru (berlaku %s):\n\n%s\n\n"+
+		"Jika kamu tidak meminta reset password, abaikan email ini.",
+		user.Name, ttl, link)
+
+	mailer.SendAsync(user.Email, "Reset password jalurku", body)
+	return nil
+}
+
+// Mengatur ulang password menggunakan token dari email
+func ResetPassword(c *fiber.Ctx) error {
+	type ResetInput struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	input := new(ResetInput)
+	if err := c.BodyParser(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid JSON format",
+			"data":    err.Error(),
+		})
+	}
+
+	if input.Token == "" || input.Password == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Token and password are required",
+			"data":    nil,
+		})
+	}
+
+	if err := password.Validate(input.Password); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+
+	userID, err := token.ConsumeOneTime(token.PurposePasswordReset, input.Token)
+	if errors.Is(err, token.ErrInvalidOneTime) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired reset token",
+			"data":    nil,
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal Server Error",
+			"data":    err.Error(),
+		})
+	}
+
+	hash, err := hashPassword(input.Password)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't hash password",
+			"data":    err.Error(),
+		})
+	}
+
+	db := database.DB
+	// Reset password juga memenuhi kewajiban reset dari admin
+	result := db.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"password":                hash,
+		"password_reset_required": false,
+	})
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't update password",
+			"data":    result.Error.Error(),
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired reset token",
+			"data":    nil,
+		})
+	}
+
+	// Semua sesi lama tidak berlaku lagi setelah password diganti
+	if err := token.RevokeUser(userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't revoke existing sessions",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Password has been reset",
+		"data":    nil,
+	})
+}
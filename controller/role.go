@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"errors"
+
+	"jalurku/database"
+	"jalurku/model"
+	"jalurku/token"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ============================================
+// ROLE HANDLERS (ADMIN)
+// ============================================
+
+// Role yang hanya boleh diberikan atau dicabut oleh superadmin
+func privilegedRole(role string) bool {
+	return role == model.RoleAdmin || role == model.RoleSuperadmin
+}
+
+// Alasan admin tidak boleh mengubah akun pengguna: akun sendiri, atau akun admin
+// (dan pemberian role admin) yang hanya boleh diatur superadmin
+func manageBlocked(c *fiber.Ctx, user *model.User, newRole string) string {
+	claims := c.Locals("user").(*jwt.Token).Claims.(jwt.MapClaims)
+	if claims["user_id"] == user.ID.String() {
+		return "You can't manage your own account"
+	}
+	if (privilegedRole(user.Role) || privilegedRole(newRole)) && claims["role"] != model.RoleSuperadmin {
+		return "Only superadmin can manage admin accounts"
+	}
+	return ""
+}
+
+// Daftar role beserta permission-nya
+func GetRoles(c *fiber.Ctx) error {
+	var roles []model.Role
+	if err := database.DB.Preload("Permissions").Order("id").Find(&roles).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Database error",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Roles found",
+		"data":    roles,
+	})
+}
+
+// Mengganti role pengguna, token lama pengguna dicabut agar permission baru berlaku
+func AssignRole(c *fiber.Ctx) error {
+	type RoleInput struct {
+		Role string `json:"role"`
+	}
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid user ID",
+			"data":    nil,
+		})
+	}
+
+	var input RoleInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Review your input",
+			"data":    err.Error(),
+		})
+	}
+
+	db := database.DB
+	var role model.Role
+	if err := db.Where("name = ?", input.Role).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Unknown role",
+				"data":    nil,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Database error",
+			"data":    err.Error(),
+		})
+	}
+
+	var user model.User
+	if err := db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "User not found",
+				"data":    nil,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Database error",
+			"data":    err.Error(),
+		})
+	}
+
+	if msg := manageBlocked(c, &user, role.Name); msg != "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": msg,
+			"data":    nil,
+		})
+	}
+
+	if err := db.Model(&user).Update("role", role.Name).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't update role",
+			"data":    err.Error(),
+		})
+	}
+
+	if err := token.RevokeUser(user.ID.String()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't revoke tokens",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Role updated",
+		"data": fiber.Map{
+			"id":   user.ID,
+			"role": role.Name,
+		},
+	})
+}
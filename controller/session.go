@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"errors"
+
+	"jalurku/token"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ============================================
+// SESSION HANDLERS
+// ============================================
+
+// Daftar sesi log masuk pengguna, sesi yang sedang dipakai ditandai current
+func GetSessions(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Token).Claims.(jwt.MapClaims)
+	userID, _ := claims["user_id"].(string)
+	sid, _ := claims["sid"].(string)
+
+	sessions, err := token.ListSessions(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't load sessions",
+			"data":    err.Error(),
+		})
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == sid
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Sessions found",
+		"data":    sessions,
+	})
+}
+
+// Mencabut satu sesi, misalnya log keluar dari komputer lab
+func RevokeSession(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Token).Claims.(jwt.MapClaims)
+	userID, _ := claims["user_id"].(string)
+
+	err := token.RevokeSession(userID, c.Params("id"))
+	if errors.Is(err, token.ErrSessionNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Session not found",
+			"data":    nil,
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't revoke session",
+			"data":    err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Session revoked",
+		"data":    nil,
+	})
+}
+
+// Mencabut semua sesi selain sesi yang sedang dipakai
+func RevokeOtherSessions(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Token).Claims.(jwt.MapClaims)
+	userID, _ := claims["user_id"].(string)
+	sid, _ := claims["sid"].(string)
+
+	sessions, err := token.ListSessions(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't load sessions",
+			"data":    err.Error(),
+		})
+	}
+
+	revoked := 0
+	for _, s := range sessions {
+		if s.ID == sid {
+			continue
+		}
+		err := token.RevokeSession(userID, s.ID)
+		if err != nil && !errors.Is(err, token.ErrSessionNotFound) {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Couldn't revoke session",
+				"data":    err.Error(),
+			})
+		}
+		// Sesi yang sudah berakhir sendiri tidak dihitung
+		if err == nil {
+			revoked++
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Other sessions revoked",
+		"data": fiber.Map{
+			"revoked": revoked,
+		},
+	})
+}
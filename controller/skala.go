@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"strings"
+
+	"jalurku/database"
+	"jalurku/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GET: Dapatkan semua skala jawaban beserta pilihannya
+func GetSkala(c *fiber.Ctx) error {
+	var skala []model.Skala
+	if err := database.DB.Preload("Opsi", model.PreloadOpsi).Order("id").Find(&skala).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Gagal mengambil data skala",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Berhasil mengambil data skala",
+		"data":    skala,
+	})
+}
+
+// POST: Membuat skala jawaban custom, contoh pilihan berlabel dengan skor masing-masing
+func CreateSkala(c *fiber.Ctx) error {
+	var input model.Skala
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Gagal membaca input",
+			"error":   err.Error(),
+		})
+	}
+
+	// score tidak ikut terbaca dari model.OpsiSkala karena disembunyikan dari siswa
+	var skor struct {
+		Opsi []struct {
+			Score int `json:"score"`
+		} `json:"opsi"`
+	}
+	if err := c.BodyParser(&skor); err != nil || len(skor.Opsi) != len(input.Opsi) {
+		return c.Status(400).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Skor pilihan tidak valid",
+		})
+	}
+
+	input.ID = 0
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" || len(input.Opsi) < 2 {
+		return c.Status(400).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Nama dan minimal dua pilihan wajib diisi",
+		})
+	}
+
+	switch input.Type {
+	case "":
+		input.Type = model.SkalaCustom
+	case model.SkalaLikert, model.SkalaYaTidak, model.SkalaCustom:
+	default:
+		return c.Status(400).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Jenis skala tidak valid",
+		})
+	}
+
+	values := make(map[int]bool)
+	for i := range input.Opsi {
+		o := &input.Opsi[i]
+		o.ID = 0
+		o.Label = strings.TrimSpace(o.Label)
+		if o.Label == "" || values[o.Value] {
+			return c.Status(400).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Setiap pilihan harus memiliki label dan nilai yang berbeda",
+			})
+		}
+		values[o.Value] = true
+		o.Score = skor.Opsi[i].Score
+		o.Urutan = i + 1
+	}
+
+	if err := database.DB.Create(&input).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Gagal membuat skala",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Skala berhasil dibuat",
+		"data": fiber.Map{
+			"id":   input.ID,
+			"name": input.Name,
+			"type": input.Type,
+			"opsi": opsiDenganSkor(input.Opsi),
+		},
+	})
+}
+
+// Pilihan jawaban beserta skornya, hanya untuk pengelola pertanyaan
+func opsiDenganSkor(opsi []model.OpsiSkala) []fiber.Map {
+	res := make([]fiber.Map, len(opsi))
+	for i, o := range opsi {
+		res[i] = fiber.Map{"value": o.Value, "label": o.Label, "score": o.Score}
+	}
+	return res
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"math"
+
+	"jalurku/database"
+	"jalurku/model"
+)
+
+// Skor satu jurusan dari jawaban angket
+type nilaiJurusan struct {
+	Skor float64 `json:"skor"`        // Jumlah skor x bobot
+	Min  float64 `json:"skor_min"`    // Skor terendah yang mungkin dari item yang dijawab
+	Max  float64 `json:"skor_maks"`   // Skor tertinggi yang mungkin dari item yang dijawab
+	Item int     `json:"jumlah_item"` // Jumlah pertanyaan yang dihitung untuk jurusan ini
+}
+
+// Skor dinormalisasi ke 0-100, agar jurusan dengan lebih banyak pertanyaan tidak selalu menang
+func (n *nilaiJurusan) Persen() float64 {
+	if n.Max <= n.Min {
+		return 0
+	}
+	return math.Round((n.Skor-n.Min)/(n.Max-n.Min)*10000) / 100
+}
+
+// Skor jawaban setelah memperhitungkan item terbalik (reverse-scored),
+// contoh Likert 1-5: 5 menjadi 1, 4 menjadi 2
+func skorJawaban(p *model.Pertanyaan, opsi model.OpsiSkala) (skor, min, max float64) {
+	min, max = math.Inf(1), math.Inf(-1)
+	for _, o := range p.Skala.Opsi {
+		min = math.Min(min, float64(o.Score))
+		max = math.Max(max, float64(o.Score))
+	}
+
+	skor = float64(opsi.Score)
+	if p.Reverse {
+		skor = min + max - skor
+	}
+	return skor, min, max
+}
+
+// Hitung skor setiap jurusan dari jawaban.
+// Jawaban dengan pertanyaan atau pilihan yang tidak valid dilewati.
+func hitungSkor(answers []model.SubmitRequest) map[int]*nilaiJurusan {
+	hasil := make(map[int]*nilaiJurusan)
+
+	// Pertanyaan, skala dan bobot untuk semua jawaban dimuat sekaligus
+	ids := make([]string, 0, len(answers))
+	for _, ans := range answers {
+		ids = append(ids, ans.QuestionID)
+	}
+	var daftar []model.Pertanyaan
+	if err := database.DB.Preload("Skala.Opsi").Preload("Bobot").Where("id IN ?", ids).Find(&daftar).Error; err != nil {
+		return hasil
+	}
+	pertanyaan := make(map[string]*model.Pertanyaan, len(daftar))
+	for i := range daftar {
+		pertanyaan[daftar[i].ID.String()] = &daftar[i]
+	}
+
+	for _, ans := range answers {
+		p, ok := pertanyaan[ans.QuestionID]
+		if !ok {
+			continue
+		}
+		// Skor diambil dari pilihan jawaban, bukan angka mentah dari klien
+		opsi, ok := p.Skala.FindOpsi(ans.SelectedOption)
+		if !ok {
+			continue
+		}
+		skor, min, max := skorJawaban(p, opsi)
+
+		// Satu pertanyaan bisa menambah (atau mengurangi) skor beberapa jurusan
+		for _, b := range p.BobotEfektif() {
+			n := hasil[b.JurusanID]
+			if n == nil {
+				n = &nilaiJurusan{}
+				hasil[b.JurusanID] = n
+			}
+			n.Skor += skor * b.Bobot
+			// Bobot negatif membalik batas skor
+			n.Min += math.Min(min*b.Bobot, max*b.Bobot)
+			n.Max += math.Max(min*b.Bobot, max*b.Bobot)
+			n.Item++
+		}
+	}
+	return hasil
+}
@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"testing"
+
+	"jalurku/database"
+	"jalurku/model"
+
+	"github.com/google/uuid"
+)
+
+// Pertanyaan untuk pengujian skor, bobot nil berarti bobot bawaan (1) ke jurusan 1
+type soalSkor struct {
+	reverse bool
+	bobot   map[int]float64
+}
+
+// Membuat skala Likert 1-5 dan pertanyaan sesuai spesifikasi, mengembalikan ID pertanyaan
+func buatSoalSkor(t *testing.T, soal []soalSkor) []string {
+	t.Helper()
+	db := database.DB
+
+	for _, j := range []model.Jurusan{{ID: 1, Name: "PG"}, {ID: 2, Name: "RPL"}} {
+		if err := db.FirstOrCreate(&j, model.Jurusan{ID: j.ID}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	skala := model.Skala{Name: "Likert " + uuid.NewString(), Type: model.SkalaLikert}
+	for v := 1; v <= 5; v++ {
+		skala.Opsi = append(skala.Opsi, model.OpsiSkala{Value: v, Label: "Opsi", Score: v, Urutan: v})
+	}
+	if err := db.Create(&skala).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make([]string, len(soal))
+	for i, s := range soal {
+		p := model.Pertanyaan{ID: uuid.New(), Text: "Soal", JurusanID: 1, SkalaID: skala.ID, Reverse: s.reverse}
+		if err := db.Omit("Skala", "Jurusan").Create(&p).Error; err != nil {
+			t.Fatal(err)
+		}
+		for jurusanID, bobot := range s.bobot {
+			if err := db.Create(&model.BobotJurusan{PertanyaanID: p.ID, JurusanID: jurusanID, Bobot: bobot}).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		ids[i] = p.ID.String()
+	}
+	return ids
+}
+
+func TestHitungSkor(t *testing.T) {
+	type jawab struct {
+		soal    int // indeks pertanyaan, -1 untuk pertanyaan yang tidak ada
+		pilihan int
+	}
+	tests := []struct {
+		name    string
+		soal    []soalSkor
+		jawaban []jawab
+		want    map[int]nilaiJurusan
+		persen  map[int]float64
+	}{
+		{
+			name:    "all negative weights, highest answers",
+			soal:    []soalSkor{{bobot: map[int]float64{1: -1}}, {bobot: map[int]float64{1: -2}}},
+			jawaban: []jawab{{0, 5}, {1, 5}},
+			want:    map[int]nilaiJurusan{1: {Skor: -15, Min: -15, Max: -3, Item: 2}},
+			persen:  map[int]float64{1: 0},
+		},
+		{
+			name:    "all negative weights, lowest answers",
+			soal:    []soalSkor{{bobot: map[int]float64{1: -1}}, {bobot: map[int]float64{1: -2}}},
+			jawaban: []jawab{{0, 1}, {1, 1}},
+			want:    map[int]nilaiJurusan{1: {Skor: -3, Min: -15, Max: -3, Item: 2}},
+			persen:  map[int]float64{1: 100},
+		},
+		{
+			name:    "mixed weights across majors",
+			soal:    []soalSkor{{bobot: map[int]float64{1: 1, 2: -0.5}}},
+			jawaban: []jawab{{0, 4}},
+			want: map[int]nilaiJurusan{
+				1: {Skor: 4, Min: 1, Max: 5, Item: 1},
+				2: {Skor: -2, Min: -2.5, Max: -0.5, Item: 1},
+			},
+			persen: map[int]float64{1: 75, 2: 25},
+		},
+		{
+			name:    "reverse item",
+			soal:    []soalSkor{{reverse: true}},
+			jawaban: []jawab{{0, 5}},
+			want:    map[int]nilaiJurusan{1: {Skor: 1, Min: 1, Max: 5, Item: 1}},
+			persen:  map[int]float64{1: 0},
+		},
+		{
+			name:    "reverse and normal item",
+			soal:    []soalSkor{{reverse: true}, {}},
+			jawaban: []jawab{{0, 2}, {1, 5}},
+			want:    map[int]nilaiJurusan{1: {Skor: 9, Min: 2, Max: 10, Item: 2}},
+			persen:  map[int]float64{1: 87.5},
+		},
+		{
+			name:    "reverse item with negative weight",
+			soal:    []soalSkor{{reverse: true, bobot: map[int]float64{2: -1}}},
+			jawaban: []jawab{{0, 5}},
+			want:    map[int]nilaiJurusan{2: {Skor: -1, Min: -5, Max: -1, Item: 1}},
+			persen:  map[int]float64{2: 100},
+		},
+		{
+			name:    "partially answered set",
+			soal:    []soalSkor{{}, {}, {}, {}},
+			jawaban: []jawab{{0, 4}, {2, 2}},
+			want:    map[int]nilaiJurusan{1: {Skor: 6, Min: 2, Max: 10, Item: 2}},
+			persen:  map[int]float64{1: 50},
+		},
+		{
+			name:    "invalid option and unknown question skipped",
+			soal:    []soalSkor{{}, {}},
+			jawaban: []jawab{{0, 3}, {1, 9}, {-1, 5}},
+			want:    map[int]nilaiJurusan{1: {Skor: 3, Min: 1, Max: 5, Item: 1}},
+			persen:  map[int]float64{1: 50},
+		},
+		{
+			name:    "nothing answered",
+			soal:    []soalSkor{{}},
+			jawaban: nil,
+			want:    map[int]nilaiJurusan{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := buatSoalSkor(t, tt.soal)
+
+			answers := make([]model.SubmitRequest, len(tt.jawaban))
+			for i, j := range tt.jawaban {
+				questionID := uuid.NewString()
+				if j.soal >= 0 {
+					questionID = ids[j.soal]
+				}
+				answers[i] = model.SubmitRequest{QuestionID: questionID, SelectedOption: j.pilihan}
+			}
+
+			hasil := hitungSkor(answers)
+			if len(hasil) != len(tt.want) {
+				t.Fatalf("got %d majors %v, want %d", len(hasil), hasil, len(tt.want))
+			}
+			for jurusanID, want := range tt.want {
+				got := hasil[jurusanID]
+				if got == nil {
+					t.Fatalf("major %d missing", jurusanID)
+				}
+				if *got != want {
+					t.Errorf("major %d: got %+v, want %+v", jurusanID, *got, want)
+				}
+				if p := got.Persen(); p != tt.persen[jurusanID] {
+					t.Errorf("major %d: Persen() = %v, want %v", jurusanID, p, tt.persen[jurusanID])
+				}
+			}
+		})
+	}
+}
+
+func TestPersen(t *testing.T) {
+	tests := []struct {
+		name string
+		n    nilaiJurusan
+		want float64
+	}{
+		{"lowest", nilaiJurusan{Skor: 3, Min: 3, Max: 15}, 0},
+		{"highest", nilaiJurusan{Skor: 15, Min: 3, Max: 15}, 100},
+		{"rounded to two decimals", nilaiJurusan{Skor: 2, Min: 1, Max: 4}, 33.33},
+		{"negative range", nilaiJurusan{Skor: -6, Min: -10, Max: -2}, 50},
+		{"empty range", nilaiJurusan{Skor: 0, Min: 0, Max: 0}, 0},
+		{"single-score scale", nilaiJurusan{Skor: 2, Min: 2, Max: 2}, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.n.Persen(); got != tt.want {
+			t.Errorf("%s: Persen() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
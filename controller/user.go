@@ -2,33 +2,55 @@ package controller
 
 import (
 	"errors"
+	"log"
 	"net/mail"
+	"strconv"
+	"strings"
 	"time"
 
-	"jalurku/config"
 	"jalurku/database"
+	"jalurku/middleware"
 	"jalurku/model"
+	"jalurku/password"
+	"jalurku/token"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 // ============================================
 // HELPER FUNCTIONS
 // ============================================
 
-// Hash password dengan bcrypt
-func hashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	return string(bytes), err
+// Hash password dengan algoritma dari konfigurasi (PASSWORD_HASH_ALGO)
+func hashPassword(p string) (string, error) {
+	return password.Hash(p)
 }
 
 // Membandingkan password dengan hash password
-func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+func CheckPasswordHash(p, hash string) bool {
+	ok, _ := password.Verify(p, hash)
+	return ok
+}
+
+// Membandingkan password pengguna, dan memperbarui hash-nya secara otomatis
+// jika algoritma atau parameternya sudah tidak sesuai konfigurasi
+func checkUserPassword(user *model.User, p string) bool {
+	ok, needsRehash := password.Verify(p, user.Password)
+	if !ok || !needsRehash {
+		return ok
+	}
+
+	hash, err := password.Hash(p)
+	if err != nil {
+		log.Printf("⚠️ Gagal memperbarui hash password %s: %v", user.ID, err)
+		return true
+	}
+	if err := database.DB.Model(user).Update("password", hash).Error; err != nil {
+		log.Printf("⚠️ Gagal memperbarui hash password %s: %v", user.ID, err)
+	}
+	return true
 }
 
 // Pengecek validasi pengetikan format email
@@ -53,11 +75,19 @@ func validToken(t *jwt.Token, id string) bool {
 	return userID == tokenUserID
 }
 
+// Dapatkan ID pengguna dari JWT yang sedang dipakai
+func currentUserID(c *fiber.Ctx) (uuid.UUID, error) {
+	t := c.Locals("user").(*jwt.Token)
+	claims := t.Claims.(jwt.MapClaims)
+	id, _ := claims["user_id"].(string)
+	return uuid.Parse(id)
+}
+
 // Dapatkan pengguna dari email
 func getUserByEmail(e string) (*model.User, error) {
 	db := database.DB
 	var user model.User
-	if err := db.Where(&model.User{Email: e}).First(&user).Error; err != nil {
+	if err := db.Where("LOWER(email) = ?", model.NormalizeEmail(e)).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -66,11 +96,16 @@ func getUserByEmail(e string) (*model.User, error) {
 	return &user, nil
 }
 
-// Dapatkan pengguna dari username
+// Dapatkan pengguna dari username (dinormalisasi, jadi "Budi.Santoso" sama dengan "budi.santoso")
 func getUserByUsername(u string) (*model.User, error) {
+	u = model.NormalizeUsername(u)
+	if u == "" {
+		return nil, nil
+	}
+
 	db := database.DB
 	var user model.User
-	if err := db.Where(&model.User{Name: u}).First(&user).Error; err != nil {
+	if err := db.Where("username = ?", u).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -79,6 +114,43 @@ func getUserByUsername(u string) (*model.User, error) {
 	return &user, nil
 }
 
+// Apakah username sudah dipakai pengguna lain (termasuk pengguna yang sudah dihapus)?
+func usernameTaken(username string, except uuid.UUID) bool {
+	var count int64
+	database.DB.Unscoped().Model(&model.User{}).
+		Where("username = ? AND id <> ?", username, except).
+		Count(&count)
+	return count > 0
+}
+
+// Membuat pasangan access token dan refresh token untuk pengguna,
+// sesi baru dicatat jika grant belum memiliki sesi
+func generateTokenPair(c *fiber.Ctx, user *model.User, grant token.Grant) (fiber.Map, error) {
+	if grant.SessionID == "" {
+		sid, err := token.StartSession(user.ID.String(), c.Get(fiber.HeaderUserAgent), c.IP())
+		if err != nil {
+			return nil, err
+		}
+		grant.SessionID = sid
+	}
+
+	t, err := token.NewAccessToken(user, grant)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := token.IssueRefresh(grant)
+	if err != nil {
+		return nil, err
+	}
+
+	return fiber.Map{
+		"token":         t,
+		"refresh_token": refresh,
+		"expires_in":    int(token.AccessTTL().Seconds()),
+	}, nil
+}
+
 // Autentikasi keaslian pengguna, dicek dari kesamaan password dan hash password.
 func validUser(id string, p string) bool {
 	db := database.DB
@@ -108,13 +180,6 @@ func Login(c *fiber.Ctx) error {
 		Identity string `json:"identity"`
 		Password string `json:"password"`
 	}
-	
-	type UserData struct {
-		ID       uuid.UUID `json:"id"`
-		Username string    `json:"username"`
-		Email    string    `json:"email"`
-		Role     string    `json:"role"`
-	}
 
 	// Memastikan format data adalah application/json
 	if string(c.Request().Header.ContentType()) != "application/json" {
@@ -162,9 +227,22 @@ func Login(c *fiber.Ctx) error {
 		})
 	}
 
+	// Tunda percobaan jika akun (atau identitas yang tidak terdaftar) atau IP ini
+	// sudah terlalu sering gagal
+	subject := loginSubject(identity, userModel)
+	if wait := loginRetryAfter(subject, c.IP()); wait > 0 {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(wait.Round(time.Second).Seconds())+1))
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Too many failed login attempts, try again later",
+			"data":    nil,
+		})
+	}
+
 	if userModel == nil {
 		// Menghindari penyerangan timing
 		CheckPasswordHash(pass, "")
+		registerLoginFailure(subject, c.IP())
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"status":  "error",
 			"message": "Invalid identity or password",
@@ -172,7 +250,8 @@ func Login(c *fiber.Ctx) error {
 		})
 	}
 
-	if !CheckPasswordHash(pass, userModel.Password) {
+	if !checkUserPassword(userModel, pass) {
+		registerLoginFailure(subject, c.IP())
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"status":  "error",
 			"message": "Invalid identity or password",
@@ -180,15 +259,64 @@ func Login(c *fiber.Ctx) error {
 		})
 	}
 
-	// Buat token JWT
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
-	claims["username"] = userModel.Name
-	claims["user_id"] = userModel.ID.String()
-	claims["role"] = userModel.Role
-	claims["exp"] = time.Now().Add(time.Hour * 72).Unix()
+	clearLoginFailures(subject)
+
+	if userModel.EmailVerifiedAt == nil && requireEmailVerification() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Email not verified",
+			"data":    nil,
+		})
+	}
+
+	if msg := loginBlocked(userModel); msg != "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": msg,
+			"data":    nil,
+		})
+	}
+
+	// Verifikasi dua langkah: token baru diberikan setelah kode TOTP benar
+	if userModel.MFAEnabledAt != nil {
+		return mfaChallenge(c, userModel)
+	}
+
+	return loginSuccess(c, userModel, token.Grant{UserID: userModel.ID.String()})
+}
+
+// Alasan akun tidak boleh log masuk (dinonaktifkan atau wajib reset password)
+func loginBlocked(user *model.User) string {
+	if user.DisabledAt != nil {
+		return "Account disabled"
+	}
+	if user.PasswordResetRequired {
+		return "Password reset required"
+	}
+	return ""
+}
+
+// Response log masuk berhasil, berisi pasangan token dan data pengguna
+func loginSuccess(c *fiber.Ctx, user *model.User, grant token.Grant) error {
+	type UserData struct {
+		ID       uuid.UUID `json:"id"`
+		Username string    `json:"username"`
+		Name     string    `json:"name"`
+		Email    string    `json:"email"`
+		Role     string    `json:"role"`
+	}
+
+	// Juga berlaku untuk log masuk lewat MFA dan OIDC
+	if msg := loginBlocked(user); msg != "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": msg,
+			"data":    nil,
+		})
+	}
 
-	t, err := token.SignedString([]byte(config.Config("SECRET")))
+	// Buat access token dan refresh token
+	tokens, err := generateTokenPair(c, user, grant)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  "error",
@@ -197,20 +325,178 @@ func Login(c *fiber.Ctx) error {
 		})
 	}
 
-	userData := UserData{
-		ID:       userModel.ID,
-		Username: userModel.Name,
-		Email:    userModel.Email,
-		Role:     userModel.Role,
+	tokens["user"] = UserData{
+		ID:       user.ID,
+		Username: user.Username,
+		Name:     user.Name,
+		Email:    user.Email,
+		Role:     user.Role,
+	}
+
+	tokens, err = respondTokens(c, tokens, wantsCookie(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not generate token",
+			"data":    nil,
+		})
 	}
 
 	return c.JSON(fiber.Map{
 		"status":  "success",
 		"message": "Success login",
-		"data": fiber.Map{
-			"token": t,
-			"user":  userData,
-		},
+		"data":    tokens,
+	})
+}
+
+// Menukar refresh token dengan pasangan token baru
+func Refresh(c *fiber.Ctx) error {
+	type RefreshInput struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	input := new(RefreshInput)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Invalid JSON format",
+				"data":    err.Error(),
+			})
+		}
+	}
+
+	// Klien browser mengirim refresh token lewat cookie
+	cookie := wantsCookie(c)
+	if input.RefreshToken == "" {
+		input.RefreshToken = c.Cookies(middleware.CookieRefresh)
+		cookie = cookie || input.RefreshToken != ""
+	}
+
+	if input.RefreshToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Refresh token is required",
+			"data":    nil,
+		})
+	}
+
+	grant, newRefresh, err := token.RotateRefresh(input.RefreshToken)
+	if errors.Is(err, token.ErrInvalidRefresh) || errors.Is(err, token.ErrRefreshReused) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired refresh token",
+			"data":    nil,
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal Server Error",
+			"data":    err.Error(),
+		})
+	}
+
+	// Ambil ulang pengguna agar role di token selalu terbaru
+	db := database.DB
+	var user model.User
+	if err := db.Where("id = ?", grant.UserID).First(&user).Error; err != nil || loginBlocked(&user) != "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired refresh token",
+			"data":    nil,
+		})
+	}
+
+	token.TouchSession(grant.SessionID, c.IP())
+
+	t, err := token.NewAccessToken(&user, grant)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not generate token",
+			"data":    nil,
+		})
+	}
+
+	tokens, err := respondTokens(c, fiber.Map{
+		"token":         t,
+		"refresh_token": newRefresh,
+		"expires_in":    int(token.AccessTTL().Seconds()),
+	}, cookie)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not generate token",
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Token refreshed",
+		"data":    tokens,
+	})
+}
+
+// Log keluar, cabut access token sekarang dan refresh token (jika dikirim)
+func Logout(c *fiber.Ctx) error {
+	type LogoutInput struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	var input LogoutInput
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Invalid JSON format",
+				"data":    err.Error(),
+			})
+		}
+	}
+
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	if err := token.Revoke(claims); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't revoke token",
+			"data":    err.Error(),
+		})
+	}
+
+	// Sesi dari access token ini ikut berakhir
+	if sid, _ := claims["sid"].(string); sid != "" {
+		if err := token.RevokeFamily(sid); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Couldn't revoke session",
+				"data":    err.Error(),
+			})
+		}
+	}
+
+	if input.RefreshToken == "" {
+		input.RefreshToken = c.Cookies(middleware.CookieRefresh)
+	}
+
+	if input.RefreshToken != "" {
+		if err := token.RevokeRefresh(input.RefreshToken); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Couldn't revoke refresh token",
+				"data":    err.Error(),
+			})
+		}
+	}
+
+	clearAuthCookies(c)
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Success logout",
+		"data":    nil,
 	})
 }
 
@@ -218,15 +504,18 @@ func Login(c *fiber.Ctx) error {
 func Register(c *fiber.Ctx) error {
 	type RegisterInput struct {
 		Name     string `json:"name" validate:"required"`
+		Username string `json:"username"`
 		Email    string `json:"email" validate:"required,email"`
-		Password string `json:"password" validate:"required,min=6"`
+		Password string `json:"password" validate:"required"`
 	}
 
 	type NewUser struct {
-		ID       uuid.UUID `json:"id"`
-		Username string    `json:"username"`
-		Email    string    `json:"email"`
-		Role     string    `json:"role"`
+		ID            uuid.UUID `json:"id"`
+		Username      string    `json:"username"`
+		Name          string    `json:"name"`
+		Email         string    `json:"email"`
+		Role          string    `json:"role"`
+		EmailVerified bool      `json:"email_verified"`
 	}
 
 	// Memastikan format data adalah application/json
@@ -257,10 +546,10 @@ func Register(c *fiber.Ctx) error {
 		})
 	}
 
-	if len(input.Password) < 6 {
+	if err := password.Validate(input.Password); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status":  "error",
-			"message": "Password must be at least 6 characters",
+			"message": err.Error(),
 			"data":    nil,
 		})
 	}
@@ -273,10 +562,11 @@ func Register(c *fiber.Ctx) error {
 		})
 	}
 
-	// Apakah email sudah ada di database?
+	// Apakah email sudah ada di database? (tanpa membedakan huruf besar kecil)
 	db := database.DB
+	email := model.NormalizeEmail(input.Email)
 	var existingUser model.User
-	if err := db.Where("email = ?", input.Email).First(&existingUser).Error; err == nil {
+	if err := db.Unscoped().Where("LOWER(email) = ?", email).First(&existingUser).Error; err == nil {
 		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 			"status":  "error",
 			"message": "Email already registered",
@@ -284,6 +574,40 @@ func Register(c *fiber.Ctx) error {
 		})
 	}
 
+	// Username opsional, dibuat dari nama jika kosong
+	var username string
+	var err error
+	if input.Username != "" {
+		username = model.NormalizeUsername(input.Username)
+		if !model.ValidUsername(username) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Username must be 3-30 characters of letters, numbers, dots or underscores",
+				"data":    nil,
+			})
+		}
+		if usernameTaken(username, uuid.Nil) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Username already taken",
+				"data":    nil,
+			})
+		}
+	} else {
+		base := input.Name
+		if model.NormalizeUsername(base) == "" {
+			base = strings.Split(email, "@")[0]
+		}
+		username, err = model.UniqueUsername(db, base)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Couldn't create user",
+				"data":    err.Error(),
+			})
+		}
+	}
+
 	// Hash password
 	hash, err := hashPassword(input.Password)
 	if err != nil {
@@ -297,9 +621,10 @@ func Register(c *fiber.Ctx) error {
 	// Buat pengguna
 	user := model.User{
 		Name:     input.Name,
-		Email:    input.Email,
+		Username: username,
+		Email:    email,
 		Password: hash,
-		Role:     "user",
+		Role:     model.RoleSiswa,
 	}
 
 	if err := db.Create(&user).Error; err != nil {
@@ -310,11 +635,18 @@ func Register(c *fiber.Ctx) error {
 		})
 	}
 
+	// Kirim email verifikasi, kegagalan tidak membatalkan pendaftaran
+	if err := sendEmailVerification(&user); err != nil {
+		log.Printf("⚠️ Gagal membuat token verifikasi untuk %s: %v", user.Email, err)
+	}
+
 	newUser := NewUser{
-		ID:       user.ID,
-		Username: user.Name,
-		Email:    user.Email,
-		Role:     user.Role,
+		ID:            user.ID,
+		Username:      user.Username,
+		Name:          user.Name,
+		Email:         user.Email,
+		Role:          user.Role,
+		EmailVerified: false,
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -397,7 +729,8 @@ func GetCurrentUser(c *fiber.Ctx) error {
 // Memperbarui pengguna
 func UpdateUser(c *fiber.Ctx) error {
 	type UpdateUserInput struct {
-		Name string `json:"name"`
+		Name     string `json:"name"`
+		Username string `json:"username"`
 	}
 
 	id := c.Params("id")
@@ -443,6 +776,25 @@ func UpdateUser(c *fiber.Ctx) error {
 		user.Name = input.Name
 	}
 
+	if input.Username != "" {
+		username := model.NormalizeUsername(input.Username)
+		if !model.ValidUsername(username) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Username must be 3-30 characters of letters, numbers, dots or underscores",
+				"data":    nil,
+			})
+		}
+		if usernameTaken(username, user.ID) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Username already taken",
+				"data":    nil,
+			})
+		}
+		user.Username = username
+	}
+
 	if err := db.Save(&user).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  "error",
@@ -458,6 +810,122 @@ func UpdateUser(c *fiber.Ctx) error {
 	})
 }
 
+// Mengganti password, butuh password sekarang.
+// Semua token lain dicabut, dan pasangan token baru dikembalikan untuk klien ini.
+func ChangePassword(c *fiber.Ctx) error {
+	type ChangePasswordInput struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+
+	id := c.Params("id")
+	t := c.Locals("user").(*jwt.Token)
+
+	if !validToken(t, id) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Unauthorized to update this user",
+			"data":    nil,
+		})
+	}
+
+	var input ChangePasswordInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Review your input",
+			"data":    err.Error(),
+		})
+	}
+
+	if input.CurrentPassword == "" || input.NewPassword == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Current and new password are required",
+			"data":    nil,
+		})
+	}
+
+	db := database.DB
+	var user model.User
+	if err := db.Where("id = ?", id).First(&user).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "User not found",
+			"data":    nil,
+		})
+	}
+
+	if !CheckPasswordHash(input.CurrentPassword, user.Password) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid password",
+			"data":    nil,
+		})
+	}
+
+	if input.NewPassword == input.CurrentPassword {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "New password must be different from the current password",
+			"data":    nil,
+		})
+	}
+
+	if err := password.Validate(input.NewPassword); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+
+	hash, err := hashPassword(input.NewPassword)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't hash password",
+			"data":    err.Error(),
+		})
+	}
+
+	if err := db.Model(&user).Update("password", hash).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't update password",
+			"data":    err.Error(),
+		})
+	}
+
+	if err := token.RevokeUser(user.ID.String()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't revoke existing sessions",
+			"data":    err.Error(),
+		})
+	}
+
+	// Klien ini tetap log masuk dengan token baru
+	mfa, _ := t.Claims.(jwt.MapClaims)["mfa"].(bool)
+	tokens, err := generateTokenPair(c, &user, token.Grant{UserID: user.ID.String(), MFA: mfa})
+	if err == nil {
+		tokens, err = respondTokens(c, tokens, wantsCookie(c) || c.Get(fiber.HeaderAuthorization) == "")
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not generate token",
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Password successfully changed",
+		"data":    tokens,
+	})
+}
+
 // Menghapus pengguna
 func DeleteUser(c *fiber.Ctx) error {
 	type PasswordInput struct {
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"jalurku/config"
+	"jalurku/database"
+	"jalurku/mailer"
+	"jalurku/model"
+	"jalurku/token"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ============================================
+// EMAIL VERIFICATION HANDLERS
+// ============================================
+
+// Apakah pengguna yang belum verifikasi email boleh log masuk?
+func requireEmailVerification() bool {
+	return config.BoolWithDefault("REQUIRE_EMAIL_VERIFICATION", false)
+}
+
+// Apakah hasil angket pengguna yang belum verifikasi email tetap disimpan?
+func keepUnverifiedResults() bool {
+	return config.BoolWithDefault("KEEP_UNVERIFIED_RESULTS", true)
+}
+
+// Membuat token verifikasi dan mengirimkannya ke email pengguna
+func sendEmailVerification(user *model.User) error {
+	ttl := config.DurationWithDefault("EMAIL_VERIFY_EXPIRE", 24*time.Hour)
+	raw, err := token.IssueOneTime(token.PurposeEmailVerify, user.ID.String(), ttl)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s",
+		strings.TrimRight(config.ConfigWithDefault("APP_URL", "http://localhost:3000"), "/"), raw)
+	body := fmt.Sprintf("Halo %s,\n\n"+
+		"Terima kasih telah mendaftar di jalurku.\n"+
+		"Buka tautan berikut untuk memverifikasi email kamu (berlaku %s):\n\n%s\n\n"+
+		"Jika kamu tidak merasa mendaftar, abaikan email ini.",
+		user.Name, ttl, link)
+
+	mailer.SendAsync(user.Email, "Verifikasi email jalurku", body)
+	return nil
+}
+
+// Konfirmasi email menggunakan token dari email verifikasi
+func VerifyEmail(c *fiber.Ctx) error {
+	type VerifyInput struct {
+		Token string `json:"token"`
+	}
+
+	input := new(VerifyInput)
+	if err := c.BodyParser(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid JSON format",
+			"data":    err.Error(),
+		})
+	}
+
+	if input.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Token is required",
+			"data":    nil,
+		})
+	}
+
+	userID, err := token.ConsumeOneTime(token.PurposeEmailVerify, input.Token)
+	if errors.Is(err, token.ErrInvalidOneTime) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired verification token",
+			"data":    nil,
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal Server Error",
+			"data":    err.Error(),
+		})
+	}
+
+	db := database.DB
+	result := db.Model(&model.User{}).
+		Where("id = ? AND email_verified_at IS NULL", userID).
+		Update("email_verified_at", time.Now())
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Couldn't verify email",
+			"data":    result.Error.Error(),
+		})
+	}
+
+	// Pengguna sudah dihapus atau email sudah diverifikasi lewat cara lain
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired verification token",
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Email verified",
+		"data":    nil,
+	})
+}
+
+// Mengirim ulang email verifikasi.
+// Response selalu sama agar tidak membocorkan email yang terdaftar.
+func ResendVerification(c *fiber.Ctx) error {
+	type ResendInput struct {
+		Email string `json:"email"`
+	}
+
+	input := new(ResendInput)
+	if err := c.BodyParser(input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid JSON format",
+			"data":    err.Error(),
+		})
+	}
+
+	if !isEmail(input.Email) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid email format",
+			"data":    nil,
+		})
+	}
+
+	// Satu alamat email hanya bisa meminta ulang sekali per jeda waktu
+	cooldown := config.DurationWithDefault("EMAIL_VERIFY_RESEND_COOLDOWN", time.Minute)
+	key := "verify_resend:" + model.NormalizeEmail(input.Email)
+	allowed, err := database.RedisClient.SetNX(context.Background(), key, 1, cooldown).Result()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal Server Error",
+			"data":    err.Error(),
+		})
+	}
+
+	if allowed {
+		user, err := getUserByEmail(input.Email)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Internal Server Error",
+				"data":    err.Error(),
+			})
+		}
+
+		if user != nil && user.EmailVerifiedAt == nil {
+			if err := sendEmailVerification(user); err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"status":  "error",
+					"message": "Couldn't create verification token",
+					"data":    nil,
+				})
+			}
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "If the email is registered and not yet verified, a verification link has been sent",
+		"data":    nil,
+	})
+}
@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"log"
+
+	"jalurku/config"
+)
+
+// Pengirim email, implementasinya dipilih dari MAIL_DRIVER
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// Mailer yang dipakai aplikasi
+var Default Mailer
+
+// Memilih implementasi mailer sesuai konfigurasi
+func Setup() {
+	switch config.ConfigWithDefault("MAIL_DRIVER", "log") {
+	case "smtp":
+		Default = &SMTPMailer{
+			Host:     config.Config("SMTP_HOST"),
+			Port:     config.ConfigWithDefault("SMTP_PORT", "587"),
+			Username: config.Config("SMTP_USERNAME"),
+			Password: config.Config("SMTP_PASSWORD"),
+			From:     config.Config("MAIL_FROM"),
+		}
+		log.Println("✅ Mailer menggunakan SMTP")
+	default:
+		Default = &LogMailer{Path: config.Config("MAIL_LOG_PATH")}
+		log.Println("📄 Mailer menggunakan log (email tidak benar-benar dikirim)")
+	}
+}
+
+// Mengirim email di latar belakang, agar waktu response tidak membocorkan
+// apakah alamat email terdaftar atau tidak
+func SendAsync(to, subject, body string) {
+	go func() {
+		if err := Default.Send(to, subject, body); err != nil {
+			log.Printf("⚠️ Gagal mengirim email ke %s: %v", to, err)
+		}
+	}()
+}
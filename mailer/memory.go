@@ -0,0 +1,23 @@
+package mailer
+
+// Email yang tersimpan di MemoryMailer
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Menyimpan email di memori tanpa mengirimnya, untuk pengujian.
+// Pasang dengan mailer.Default = mailer.NewMemoryMailer(), lalu baca email dari Outbox.
+type MemoryMailer struct {
+	Outbox chan Message
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{Outbox: make(chan Message, 100)}
+}
+
+func (m *MemoryMailer) Send(to, subject, body string) error {
+	m.Outbox <- Message{To: to, Subject: subject, Body: body}
+	return nil
+}
@@ -4,11 +4,14 @@ import (
 	"flag"
 	"log"
 	"os"
+	"strings"
 
 	"jalurku/config"
 	"jalurku/database"
+	"jalurku/mailer"
 	"jalurku/model"
 	"jalurku/route"
+	"jalurku/token"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -27,6 +30,16 @@ func main() {
 	// Connect to database
 	database.ConnectDB()
 
+	// Pengguna lama dianggap sudah terverifikasi saat kolom email_verified_at pertama kali dibuat
+	backfillVerified := database.DB.Migrator().HasTable(&model.User{}) &&
+		!database.DB.Migrator().HasColumn(&model.User{}, "EmailVerifiedAt")
+
+	// Skala bawaan harus ada sebelum kolom pertanyaan.skala_id (dan foreign key-nya) dibuat
+	if err := database.DB.AutoMigrate(&model.Skala{}, &model.OpsiSkala{}); err != nil {
+		log.Fatal("Failed to migrate database: ", err)
+	}
+	model.SeedSkala(database.DB)
+
 	// Auto migrate model
 	err := database.DB.AutoMigrate(
 		&model.User{},
@@ -34,21 +47,45 @@ func main() {
 		&model.Jurusan{},
 		&model.User{},
 		&model.HasilAngket{},
+		&model.BobotJurusan{},
+		&model.Client{},
+		&model.APIKey{},
+		&model.RecoveryCode{},
+		&model.UserIdentity{},
+		&model.SigningKey{},
+		&model.Role{},
+		&model.Permission{},
+		&model.AuditLog{},
 	)
 
+	if err == nil && backfillVerified {
+		database.DB.Exec("UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL")
+	}
+
 	model.SeedJurusan(database.DB)
+	model.SeedRoles(database.DB)
+	model.MigrateUsernames(database.DB)
+	promoteSuperadmins()
 
 	if err != nil {
 		log.Fatal("Failed to migrate database: ", err)
 	}
 	log.Println("✅ Database migration completed")
 
+	// Load JWT signing keys and start scheduled rotation
+	if err := token.InitKeys(); err != nil {
+		log.Fatal("Failed to load JWT signing keys: ", err)
+	}
+
 	// // Run seeder if flag is set
 	// if *seed {
 	// 	database.SeedDatabase()
 	// 	return
 	// }
 
+	// Setup mailer (SMTP or log)
+	mailer.Setup()
+
 	// Create Fiber app with production-ready config
 	app := fiber.New(fiber.Config{
 		ErrorHandler:          customErrorHandler,
@@ -79,16 +116,15 @@ func main() {
 	// CORS middleware
 	corsConfig := cors.Config{
 		AllowOrigins: "*",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-API-Key, X-Auth-Mode, X-CSRF-Token, X-Device-Token, Idempotency-Key",
 		AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
 	}
 
-	// Production CORS (more restrictive)
-	if config.IsProduction() {
-		allowedOrigins := os.Getenv("ALLOWED_ORIGINS")
-		if allowedOrigins != "" {
-			corsConfig.AllowOrigins = allowedOrigins
-		}
+	// Cookie sessions need explicit origins with credentials (not allowed with "*")
+	allowedOrigins := os.Getenv("ALLOWED_ORIGINS")
+	if allowedOrigins != "" {
+		corsConfig.AllowOrigins = allowedOrigins
+		corsConfig.AllowCredentials = true
 	}
 
 	app.Use(cors.New(corsConfig))
@@ -138,4 +174,16 @@ func customErrorHandler(c *fiber.Ctx, err error) error {
 		"message": message,
 		"data":    nil,
 	})
-}
\ No newline at end of file
+}
+
+// Memberi role superadmin ke akun yang terdaftar di SUPERADMIN_EMAILS
+func promoteSuperadmins() {
+	for _, email := range strings.Split(os.Getenv("SUPERADMIN_EMAILS"), ",") {
+		if strings.TrimSpace(email) == "" {
+			continue
+		}
+		if err := database.DB.Model(&model.User{}).Where("LOWER(email) = ?", model.NormalizeEmail(email)).Update("role", model.RoleSuperadmin).Error; err != nil {
+			log.Printf("Failed to promote superadmin %s: %v", email, err)
+		}
+	}
+}
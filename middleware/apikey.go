@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"time"
+
+	"jalurku/config"
+	"jalurku/database"
+	"jalurku/model"
+	"jalurku/token"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// API key statis dari API_KEY, punya semua scope.
+// Dipakai untuk pengembangan lokal dan membuat key klien pertama.
+var masterKey = &model.APIKey{Prefix: "master", Scopes: "*"}
+
+// Klien harus mengirim X-API-Key yang terdaftar dan masih aktif
+func APIKey() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		raw := c.Get("X-API-Key")
+		if raw == "" {
+			return apiKeyError(c, fiber.StatusUnauthorized, "Missing API key")
+		}
+
+		if master := config.Config("API_KEY"); master != "" &&
+			subtle.ConstantTimeCompare([]byte(raw), []byte(master)) == 1 {
+			c.Locals("api_key", masterKey)
+			return c.Next()
+		}
+
+		var key model.APIKey
+		if err := database.DB.Where("key_hash = ?", token.Hash(raw)).First(&key).Error; err != nil {
+			return apiKeyError(c, fiber.StatusUnauthorized, "Invalid API key")
+		}
+
+		if !key.Active(time.Now()) {
+			return apiKeyError(c, fiber.StatusUnauthorized, "API key expired or revoked")
+		}
+
+		c.Locals("api_key", &key)
+		return c.Next()
+	}
+}
+
+// API key harus memiliki scope tertentu, dipakai setelah APIKey()
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key, ok := c.Locals("api_key").(*model.APIKey)
+		if !ok || !key.HasScope(scope) {
+			return apiKeyError(c, fiber.StatusForbidden, "API key is not allowed to access this resource")
+		}
+		return c.Next()
+	}
+}
+
+func apiKeyError(c *fiber.Ctx, status int, message string) error {
+	return c.Status(status).JSON(fiber.Map{
+		"status":  "error",
+		"message": message,
+		"data":    nil,
+	})
+}
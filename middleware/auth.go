@@ -2,7 +2,7 @@ package middleware
 
 import (
 	"jalurku/config"
-	"os"
+	"jalurku/token"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
@@ -13,18 +13,44 @@ import (
 // Pengguna harus terdaftar dan terautentikasi melalui JWT
 func Protected() fiber.Handler {
 	return jwtware.New(jwtware.Config{
-		SigningKey:  
-		jwtware.SigningKey{
-			Key: []byte(config.Config("SECRET")),
-		},
-		ErrorHandler: jwtError,
-		ContextKey:   "user",
-		// Sementara menggunaakan Token Bearer
-		TokenLookup:  "header:Authorization",
-		AuthScheme:   "Bearer",
+		// Kunci dicari dari kid, algoritma harus sama dengan kuncinya
+		KeyFunc:        token.Keyfunc,
+		ErrorHandler:   jwtError,
+		SuccessHandler: checkClaims,
+		ContextKey:     "user",
+		// Token Bearer, atau cookie sesi untuk browser
+		TokenLookup: "header:Authorization,cookie:" + CookieToken,
+		AuthScheme:  "Bearer",
 	})
 }
 
+// Menolak token dari issuer lain dan token yang sudah dicabut (log keluar atau dicabut admin)
+func checkClaims(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+
+	if iss, _ := claims.GetIssuer(); iss != token.Issuer() {
+		return jwtError(c, jwt.ErrTokenInvalidIssuer)
+	}
+
+	if token.IsRevoked(claims) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Token has been revoked",
+			"data":    nil,
+		})
+	}
+
+	// Token dari cookie wajib disertai CSRF token
+	if usesCookie(c) && !validCSRF(c) {
+		return csrfError(c)
+	}
+
+	sid, _ := claims["sid"].(string)
+	token.TouchSession(sid, c.IP())
+	return guardImpersonation(c, claims)
+}
+
 // Error handler JWT buatan
 func jwtError(c *fiber.Ctx, err error) error {
 	if err.Error() == "Missing or malformed JWT" {
@@ -52,8 +78,9 @@ func Optional() fiber.Handler {
 		}
 
 		// 🍪 2. Kalau gak ada, coba ambil dari cookie "token"
-		if tokenStr == "" {
-			tokenStr = c.Cookies("token")
+		// (tanpa CSRF token yang valid, request dianggap guest)
+		if tokenStr == "" && validCSRF(c) {
+			tokenStr = c.Cookies(CookieToken)
 		}
 
 		// ❌ Kalau dua-duanya kosong → lanjut (guest)
@@ -62,13 +89,15 @@ func Optional() fiber.Handler {
 		}
 
 		// ✅ 3. Parse token kalau ada
-		token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("SECRET")), nil
-		})
+		t, err := token.Parse(tokenStr)
 
-		// Jika token valid → simpan di context
-		if err == nil && token.Valid {
-			c.Locals("user", token)
+		// Jika token valid dan belum dicabut → simpan di context
+		if err == nil && t.Valid && !token.IsRevoked(t.Claims.(jwt.MapClaims)) {
+			claims := t.Claims.(jwt.MapClaims)
+			c.Locals("user", t)
+			sid, _ := claims["sid"].(string)
+			token.TouchSession(sid, c.IP())
+			return guardImpersonation(c, claims)
 		}
 
 		// Apapun hasilnya (valid / invalid / kosong) tetap lanjut
@@ -76,18 +105,41 @@ func Optional() fiber.Handler {
 	}
 }
 
-// Apakah user memiliki role admin?
-func AdminOnly() fiber.Handler {
+// Apakah token pengguna memiliki permission tertentu?
+func HasPermission(claims jwt.MapClaims, perm string) bool {
+	perms, _ := claims["perms"].([]interface{})
+	for _, p := range perms {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Pengguna harus memiliki permission, contoh RequirePermission("pertanyaan:write").
+// Dipasang setelah Protected.
+func RequirePermission(perm string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		user := c.Locals("user").(*jwt.Token)
 		claims := user.Claims.(jwt.MapClaims)
-		role := claims["role"].(string)
 
-		// Mengecek apakah user memiliki role admin atau tidak
-		if role != "admin" {
-			return c.SendStatus(fiber.StatusForbidden)
+		if !HasPermission(claims, perm) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Permission denied",
+				"data":    nil,
+			})
+		}
+
+		// Akun dengan permission (admin, guru BK, wali kelas) wajib log masuk dengan verifikasi dua langkah
+		if mfa, _ := claims["mfa"].(bool); !mfa && config.BoolWithDefault("ADMIN_REQUIRE_MFA", true) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":  "error",
+				"message": "MFA required",
+				"data":    nil,
+			})
 		}
 
 		return c.Next()
 	}
-}
\ No newline at end of file
+}
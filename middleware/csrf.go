@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Nama cookie sesi browser dan header CSRF
+const (
+	CookieToken   = "token"
+	CookieRefresh = "refresh_token"
+	CookieCSRF    = "csrf_token"
+	HeaderCSRF    = "X-CSRF-Token"
+)
+
+// Apakah request memakai cookie sesi (bukan header Authorization)?
+func usesCookie(c *fiber.Ctx) bool {
+	if c.Get(fiber.HeaderAuthorization) != "" {
+		return false
+	}
+	return c.Cookies(CookieToken) != "" || c.Cookies(CookieRefresh) != ""
+}
+
+// Double-submit CSRF: untuk method yang mengubah data,
+// header X-CSRF-Token harus sama dengan cookie csrf_token
+func validCSRF(c *fiber.Ctx) bool {
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return true
+	}
+
+	cookie := c.Cookies(CookieCSRF)
+	header := c.Get(HeaderCSRF)
+	return cookie != "" && subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
+
+// Melindungi rute yang bisa diautentikasi lewat cookie sesi dari CSRF
+func CSRF() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if usesCookie(c) && !validCSRF(c) {
+			return csrfError(c)
+		}
+		return c.Next()
+	}
+}
+
+func csrfError(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"status":  "error",
+		"message": "Missing or invalid CSRF token",
+		"data":    nil,
+	})
+}
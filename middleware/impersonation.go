@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+
+	"jalurku/database"
+	"jalurku/model"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Request dengan token impersonasi hanya boleh membaca, dan setiap request dicatat di audit log
+func guardImpersonation(c *fiber.Ctx, claims jwt.MapClaims) error {
+	impersonatorID, _ := claims["impersonator_id"].(string)
+	if impersonatorID == "" {
+		return c.Next()
+	}
+
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+	default:
+		recordImpersonation(c, claims, fiber.StatusForbidden, true)
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Write actions are disabled while impersonating",
+			"data":    nil,
+		})
+	}
+
+	err := c.Next()
+
+	status := c.Response().StatusCode()
+	if err != nil {
+		status = fiber.StatusInternalServerError
+		var e *fiber.Error
+		if errors.As(err, &e) {
+			status = e.Code
+		}
+	}
+	recordImpersonation(c, claims, status, false)
+	return err
+}
+
+func recordImpersonation(c *fiber.Ctx, claims jwt.MapClaims, status int, blocked bool) {
+	impersonatorID, _ := uuid.Parse(claims["impersonator_id"].(string))
+	userID, _ := uuid.Parse(claims["user_id"].(string))
+
+	entry := model.AuditLog{
+		ImpersonatorID: impersonatorID,
+		UserID:         userID,
+		Method:         c.Method(),
+		Path:           c.OriginalURL(),
+		Status:         status,
+		Blocked:        blocked,
+		IP:             c.IP(),
+	}
+	if err := database.DB.Create(&entry).Error; err != nil {
+		log.Printf("⚠️ Gagal mencatat audit impersonasi: %v", err)
+	}
+}
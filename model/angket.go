@@ -26,10 +26,14 @@ type Pertanyaan struct {
 	Text      	string         		`gorm:"type:text;not null" json:"text"`
 	Image		string				`json:"image"`
 	JurusanID 	int            		`gorm:"not null" json:"jurusan_id"`
+	SkalaID 	int            		`gorm:"not null;default:1" json:"skala_id"` // Default Likert 1-5
+	Reverse 	bool           		`gorm:"not null;default:false" json:"-"` // Item bernada negatif, skornya dibalik. Tidak ditampilkan ke siswa
 	CreatedAt 	time.Time
 	UpdatedAt 	time.Time
 
 	Jurusan 	Jurusan 			`gorm:"foreignKey:JurusanID"`
+	Skala 		Skala 				`gorm:"foreignKey:SkalaID" json:"skala"`
+	Bobot 		[]BobotJurusan 		`gorm:"foreignKey:PertanyaanID" json:"-"` // Tidak ditampilkan ke siswa
 }
 
 // Hasil angket yang berhubungan dengan pengguna
@@ -51,6 +55,20 @@ type SubmitRequest struct {
 	SelectedOption int   `json:"selected_option"`
 }
 
+// Satu jawaban dalam submit batch, answered_at adalah waktu menjawab di perangkat klien
+type BatchJawaban struct {
+	QuestionID     string    `json:"question_id"`
+	SelectedOption int       `json:"selected_option"`
+	AnsweredAt     time.Time `json:"answered_at"`
+}
+
+// Submit banyak jawaban sekaligus (klien offline), idempotency_key mencegah batch diproses dua kali
+type BatchSubmitRequest struct {
+	SessionID      string         `json:"session_id"`
+	IdempotencyKey string         `json:"idempotency_key"`
+	Answers        []BatchJawaban `json:"answers"`
+}
+
 // Tambahkan data Jurusan -> (1:PG, 2:RPL, 3:TKJ, 4:TJA)
 func SeedJurusan(db *gorm.DB) {
 	// Hapus semua data jurusan
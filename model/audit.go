@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Catatan request yang dilakukan admin saat impersonasi pengguna
+type AuditLog struct {
+	ID             	uuid.UUID      	`gorm:"type:char(36);primaryKey" json:"id"`
+	ImpersonatorID 	uuid.UUID      	`gorm:"type:char(36);not null;index" json:"impersonator_id"`
+	UserID         	uuid.UUID      	`gorm:"type:char(36);not null;index" json:"user_id"`
+	Method         	string         	`gorm:"type:varchar(10);not null" json:"method"`
+	Path           	string         	`gorm:"type:varchar(255);not null" json:"path"`
+	Status         	int            	`json:"status"`
+	Blocked        	bool           	`gorm:"not null;default:false" json:"blocked"`	// Request tulis yang ditolak
+	IP             	string         	`gorm:"type:varchar(45)" json:"ip"`
+	CreatedAt      	time.Time      	`gorm:"index" json:"created_at"`
+}
+
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Bobot pertanyaan terhadap jurusan, boleh negatif.
+// Pertanyaan tanpa bobot dihitung penuh (bobot 1) ke JurusanID-nya.
+type BobotJurusan struct {
+	ID           	int            		`gorm:"primaryKey;autoIncrement" json:"-"`
+	PertanyaanID 	uuid.UUID      		`gorm:"type:char(36);not null;uniqueIndex:idx_bobot_pertanyaan_jurusan" json:"pertanyaan_id"`
+	JurusanID    	int            		`gorm:"not null;uniqueIndex:idx_bobot_pertanyaan_jurusan" json:"jurusan_id"`
+	Bobot        	float64        		`gorm:"not null" json:"bobot"`
+	CreatedAt    	time.Time			`json:"-"`
+	UpdatedAt    	time.Time			`json:"-"`
+
+	Jurusan      	Jurusan        		`gorm:"foreignKey:JurusanID" json:"-"`
+}
+
+// Bobot yang dipakai saat menghitung skor pertanyaan
+func (p *Pertanyaan) BobotEfektif() []BobotJurusan {
+	if len(p.Bobot) > 0 {
+		return p.Bobot
+	}
+	return []BobotJurusan{{PertanyaanID: p.ID, JurusanID: p.JurusanID, Bobot: 1}}
+}
+
+func (BobotJurusan) TableName() string {
+	return "bobot_jurusan"
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Aplikasi klien yang boleh mengakses API (web, mobile, layanan sekolah lain)
+type Client struct {
+	ID        	uuid.UUID      	`gorm:"type:char(36);primaryKey" json:"id"`
+	Name      	string         	`gorm:"type:varchar(100);not null" json:"name"`
+	CreatedAt 	time.Time		`json:"created_at"`
+	UpdatedAt 	time.Time		`json:"updated_at"`
+
+	APIKeys 	[]APIKey 		`gorm:"foreignKey:ClientID" json:"api_keys,omitempty"`
+}
+
+// API key milik klien, hanya hash-nya yang disimpan
+type APIKey struct {
+	ID        	uuid.UUID      	`gorm:"type:char(36);primaryKey" json:"id"`
+	ClientID  	uuid.UUID      	`gorm:"type:char(36);not null;index" json:"client_id"`
+	Prefix    	string         	`gorm:"type:varchar(16);not null" json:"prefix"`
+	KeyHash   	string         	`gorm:"type:char(64);uniqueIndex;not null" json:"-"`
+	Scopes    	string         	`gorm:"type:varchar(255);not null" json:"scopes"`
+	ExpiresAt 	*time.Time     	`json:"expires_at"`
+	RevokedAt 	*time.Time     	`json:"revoked_at"`
+	CreatedAt 	time.Time		`json:"created_at"`
+	UpdatedAt 	time.Time		`json:"updated_at"`
+
+	Client  	Client 			`gorm:"foreignKey:ClientID" json:"-"`
+}
+
+func (c *Client) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+// Apakah key masih bisa dipakai?
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil && !k.RevokedAt.After(now) {
+		return false
+	}
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(now) {
+		return false
+	}
+	return true
+}
+
+// Apakah key memiliki scope tertentu? Scope "*" berarti semua scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range strings.Split(k.Scopes, ",") {
+		s = strings.TrimSpace(s)
+		if s == "*" || s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (Client) TableName() string {
+	return "clients"
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
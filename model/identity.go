@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Akun pengguna di penyedia identitas luar (OIDC), ditautkan ke User
+type UserIdentity struct {
+	ID        	uuid.UUID      	`gorm:"type:char(36);primaryKey"`
+	UserID    	uuid.UUID      	`gorm:"type:char(36);not null;index"`
+	Provider  	string         	`gorm:"type:varchar(50);not null;uniqueIndex:idx_identity_provider_subject"`
+	Subject   	string         	`gorm:"type:varchar(255);not null;uniqueIndex:idx_identity_provider_subject"`
+	Email     	string         	`gorm:"type:varchar(100)"`
+	CreatedAt 	time.Time
+	UpdatedAt 	time.Time
+
+	User    	User    		`gorm:"foreignKey:UserID"`
+}
+
+func (i *UserIdentity) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
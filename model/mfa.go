@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Kode pemulihan sekali pakai untuk verifikasi dua langkah, hanya hash-nya yang disimpan
+type RecoveryCode struct {
+	ID        	uuid.UUID      	`gorm:"type:char(36);primaryKey"`
+	UserID    	uuid.UUID      	`gorm:"type:char(36);not null;index"`
+	CodeHash  	string         	`gorm:"type:char(64);not null"`
+	UsedAt    	*time.Time
+	CreatedAt 	time.Time
+}
+
+func (r *RecoveryCode) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
@@ -0,0 +1,130 @@
+package model
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Nama role bawaan
+const (
+	RoleSiswa      = "siswa"
+	RoleGuruBK     = "guru_bk"
+	RoleWaliKelas  = "wali_kelas"
+	RoleAdmin      = "admin"
+	RoleSuperadmin = "superadmin"
+)
+
+// Nama permission bawaan
+const (
+	PermPertanyaanWrite = "pertanyaan:write"
+	PermHasilRead       = "hasil:read"
+	PermUserManage      = "user:manage"
+	PermRoleManage      = "role:manage"
+	PermClientManage    = "client:manage"
+	PermUserImpersonate = "user:impersonate"
+)
+
+// Role pengguna, disimpan di kolom users.role
+type Role struct {
+	ID          	int            		`gorm:"primaryKey;autoIncrement" json:"id"`
+	Name        	string         		`gorm:"type:varchar(20);unique;not null" json:"name"`
+	Description 	string         		`gorm:"type:varchar(255)" json:"description"`
+	CreatedAt   	time.Time			`json:"-"`
+	UpdatedAt   	time.Time			`json:"-"`
+
+	Permissions 	[]Permission 		`gorm:"many2many:role_permissions" json:"permissions"`
+}
+
+// Hak akses yang bisa dimiliki role, contoh "pertanyaan:write"
+type Permission struct {
+	ID          	int            		`gorm:"primaryKey;autoIncrement" json:"id"`
+	Name        	string         		`gorm:"type:varchar(50);unique;not null" json:"name"`
+	Description 	string         		`gorm:"type:varchar(255)" json:"description"`
+	CreatedAt   	time.Time			`json:"-"`
+	UpdatedAt   	time.Time			`json:"-"`
+}
+
+// Daftar permission milik role
+func PermissionsForRole(db *gorm.DB, role string) ([]string, error) {
+	names := []string{}
+	err := db.Model(&Permission{}).
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Where("roles.name = ?", role).
+		Order("permissions.name").
+		Pluck("permissions.name", &names).Error
+	return names, err
+}
+
+// Tambahkan role dan permission bawaan, beserta relasinya.
+// Role "user" lama diubah menjadi "siswa".
+func SeedRoles(db *gorm.DB) {
+	permissions := []Permission{
+		{Name: PermPertanyaanWrite, Description: "Membuat, mengubah dan menghapus pertanyaan"},
+		{Name: PermHasilRead, Description: "Melihat hasil angket siswa"},
+		{Name: PermUserManage, Description: "Mengelola akun pengguna"},
+		{Name: PermRoleManage, Description: "Mengatur role pengguna"},
+		{Name: PermClientManage, Description: "Mengelola aplikasi klien dan API key"},
+		{Name: PermUserImpersonate, Description: "Melihat API sebagai pengguna lain (tercatat di audit)"},
+	}
+
+	roles := map[string][]string{
+		RoleSiswa:      {},
+		RoleGuruBK:     {PermHasilRead},
+		RoleWaliKelas:  {PermHasilRead},
+		RoleAdmin:      {PermPertanyaanWrite, PermHasilRead, PermUserManage, PermRoleManage, PermClientManage},
+		RoleSuperadmin: {PermPertanyaanWrite, PermHasilRead, PermUserManage, PermRoleManage, PermClientManage, PermUserImpersonate},
+	}
+
+	descriptions := map[string]string{
+		RoleSiswa:      "Siswa yang mengisi angket",
+		RoleGuruBK:     "Guru bimbingan konseling",
+		RoleWaliKelas:  "Wali kelas",
+		RoleAdmin:      "Administrator",
+		RoleSuperadmin: "Administrator utama",
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&permissions).Error; err != nil {
+			return err
+		}
+
+		for name, perms := range roles {
+			role := Role{Name: name, Description: descriptions[name]}
+			if err := tx.Where(Role{Name: name}).FirstOrCreate(&role).Error; err != nil {
+				return err
+			}
+
+			var list []Permission
+			if len(perms) > 0 {
+				if err := tx.Where("name IN ?", perms).Find(&list).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Model(&role).Association("Permissions").Append(list); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&User{}).
+			Where("role = ? OR role = '' OR role IS NULL", "user").
+			Update("role", RoleSiswa).Error
+	})
+
+	if err != nil {
+		log.Printf("Error seeding roles: %v", err)
+	} else {
+		log.Println("Roles seeded successfully!")
+	}
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+func (Permission) TableName() string {
+	return "permissions"
+}
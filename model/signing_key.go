@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// Kunci penandatangan JWT. Kunci dipakai untuk menandatangani antara ActivatesAt
+// dan RetiresAt, dan tetap dipakai untuk verifikasi (dan muncul di JWKS) sampai ExpiresAt.
+type SigningKey struct {
+	Kid         	string         	`gorm:"type:varchar(64);primaryKey"`
+	Alg         	string         	`gorm:"type:varchar(10);not null"`
+	PrivateKey  	string         	`gorm:"type:text;not null" json:"-"`
+	ActivatesAt 	time.Time      	`gorm:"not null;index"`
+	RetiresAt   	time.Time      	`gorm:"not null"`
+	ExpiresAt   	time.Time      	`gorm:"not null;index"`
+	CreatedAt   	time.Time
+}
+
+func (SigningKey) TableName() string {
+	return "signing_keys"
+}
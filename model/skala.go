@@ -0,0 +1,107 @@
+package model
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Jenis skala jawaban
+const (
+	SkalaLikert  = "likert"
+	SkalaYaTidak = "ya_tidak"
+	SkalaCustom  = "custom"
+)
+
+// Skala bawaan (1: Likert 1-5, 2: Ya/Tidak)
+const (
+	SkalaLikertID  = 1
+	SkalaYaTidakID = 2
+)
+
+// Kumpulan pilihan jawaban yang dipakai pertanyaan
+type Skala struct {
+	ID         	int            		`gorm:"primaryKey;autoIncrement" json:"id"`
+	Name       	string         		`gorm:"type:varchar(50);unique;not null" json:"name"`
+	Type       	string         		`gorm:"type:varchar(20);not null" json:"type"`
+	CreatedAt  	time.Time			`json:"-"`
+	UpdatedAt  	time.Time			`json:"-"`
+
+	Opsi       	[]OpsiSkala    		`gorm:"foreignKey:SkalaID" json:"opsi"`
+}
+
+// Pilihan jawaban, Value dikirim klien sebagai selected_option, Score dipakai saat menghitung hasil
+// dan tidak ditampilkan ke siswa
+type OpsiSkala struct {
+	ID         	int            		`gorm:"primaryKey;autoIncrement" json:"-"`
+	SkalaID    	int            		`gorm:"not null;uniqueIndex:idx_opsi_skala_value" json:"-"`
+	Value      	int            		`gorm:"not null;uniqueIndex:idx_opsi_skala_value" json:"value"`
+	Label      	string         		`gorm:"type:varchar(100);not null" json:"label"`
+	Score      	int            		`gorm:"not null" json:"-"`
+	Urutan     	int            		`gorm:"not null;default:0" json:"-"`
+}
+
+// Cari pilihan jawaban dari nilai yang dikirim klien
+func (s *Skala) FindOpsi(value int) (OpsiSkala, bool) {
+	for _, o := range s.Opsi {
+		if o.Value == value {
+			return o, true
+		}
+	}
+	return OpsiSkala{}, false
+}
+
+// Preload pilihan jawaban sesuai urutan tampil
+func PreloadOpsi(db *gorm.DB) *gorm.DB {
+	return db.Order("urutan, value")
+}
+
+// Tambahkan skala bawaan jika belum ada
+func SeedSkala(db *gorm.DB) {
+	skalaData := []Skala{
+		{ID: SkalaLikertID, Name: "Likert 1-5", Type: SkalaLikert, Opsi: []OpsiSkala{
+			{Value: 1, Label: "Sangat tidak setuju", Score: 1, Urutan: 1},
+			{Value: 2, Label: "Tidak setuju", Score: 2, Urutan: 2},
+			{Value: 3, Label: "Ragu-ragu", Score: 3, Urutan: 3},
+			{Value: 4, Label: "Setuju", Score: 4, Urutan: 4},
+			{Value: 5, Label: "Sangat setuju", Score: 5, Urutan: 5},
+		}},
+		{ID: SkalaYaTidakID, Name: "Ya/Tidak", Type: SkalaYaTidak, Opsi: []OpsiSkala{
+			{Value: 1, Label: "Ya", Score: 1, Urutan: 1},
+			{Value: 0, Label: "Tidak", Score: 0, Urutan: 2},
+		}},
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, s := range skalaData {
+			var count int64
+			if err := tx.Model(&Skala{}).Where("id = ?", s.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+			if err := tx.Create(&s).Error; err != nil {
+				return err
+			}
+		}
+
+		// ID bawaan diisi manual, sequence perlu disesuaikan agar skala baru tidak bentrok
+		return tx.Exec("SELECT setval(pg_get_serial_sequence('skala', 'id'), (SELECT MAX(id) FROM skala))").Error
+	})
+
+	if err != nil {
+		log.Printf("Error seeding skala: %v", err)
+	} else {
+		log.Println("Skala seeded successfully!")
+	}
+}
+
+func (Skala) TableName() string {
+	return "skala"
+}
+
+func (OpsiSkala) TableName() string {
+	return "opsi_skala"
+}
@@ -1,6 +1,10 @@
 package model
 
 import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,10 +14,16 @@ import (
 // Model tabel pengguna
 type User struct {
 	ID        	uuid.UUID      	`gorm:"type:char(36);primaryKey"`
-	Name      	string         	`gorm:"type:varchar(100);not null"`
+	Name      	string         	`gorm:"type:varchar(100);not null"`	// Nama tampilan, tidak unik
+	Username  	string         	`gorm:"type:varchar(30);uniqueIndex"`	// Selalu dalam bentuk NormalizeUsername
 	Email     	string         	`gorm:"type:varchar(100);unique;not null"`
 	Password  	string         	`gorm:"type:varchar(255);not null"`
-	Role      	string         	`gorm:"type:varchar(20);default:'user'"`
+	Role      	string         	`gorm:"type:varchar(20);default:'siswa'"`
+	EmailVerifiedAt	*time.Time
+	TOTPSecret	string			`gorm:"type:varchar(64)" json:"-"`
+	MFAEnabledAt	*time.Time
+	DisabledAt	*time.Time								// Akun dinonaktifkan admin
+	PasswordResetRequired	bool	`gorm:"not null;default:false"`	// Wajib reset password sebelum log masuk
 	CreatedAt 	time.Time
 	UpdatedAt 	time.Time
 	DeletedAt 	gorm.DeletedAt 	`gorm:"index"`
@@ -28,6 +38,90 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+var usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._]{2,29}$`)
+
+// Email dibandingkan tanpa membedakan huruf besar kecil
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Username huruf kecil, spasi menjadi titik, karakter selain a-z 0-9 . _ dibuang.
+// "Budi Santoso" menjadi "budi.santoso".
+func NormalizeUsername(u string) string {
+	u = strings.ToLower(strings.TrimSpace(u))
+	u = strings.Join(strings.Fields(u), ".")
+
+	var b strings.Builder
+	for _, r := range u {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '_' {
+			b.WriteRune(r)
+		}
+	}
+	return strings.Trim(b.String(), "._")
+}
+
+// Apakah username (yang sudah dinormalisasi) memenuhi format?
+func ValidUsername(u string) bool {
+	return usernamePattern.MatchString(u)
+}
+
+// Membuat username unik dari nama atau email, ditambah angka jika sudah dipakai
+func UniqueUsername(db *gorm.DB, base string) (string, error) {
+	base = NormalizeUsername(base)
+	if len(base) > 25 {
+		base = strings.TrimRight(base[:25], "._")
+	}
+	for len(base) < 3 {
+		base += "0"
+	}
+
+	candidate := base
+	for i := 2; ; i++ {
+		var count int64
+		if err := db.Unscoped().Model(&User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s%d", base, i)
+	}
+}
+
+// Mengisi username pengguna lama dari namanya, dan membuat index unik email tanpa
+// membedakan huruf besar kecil
+func MigrateUsernames(db *gorm.DB) {
+	var users []User
+	if err := db.Unscoped().Where("username IS NULL OR username = ''").Order("created_at").Find(&users).Error; err != nil {
+		log.Printf("Error migrating usernames: %v", err)
+		return
+	}
+
+	for _, user := range users {
+		base := user.Name
+		if NormalizeUsername(base) == "" {
+			base = strings.Split(user.Email, "@")[0]
+		}
+		username, err := UniqueUsername(db, base)
+		if err != nil {
+			log.Printf("Error migrating usernames: %v", err)
+			return
+		}
+		if err := db.Unscoped().Model(&user).Update("username", username).Error; err != nil {
+			log.Printf("Error migrating usernames: %v", err)
+			return
+		}
+	}
+	if len(users) > 0 {
+		log.Printf("Usernames generated for %d users", len(users))
+	}
+
+	// Gagal jika masih ada email yang sama dengan huruf berbeda, perlu dirapikan manual
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (LOWER(email))").Error; err != nil {
+		log.Printf("Error creating case-insensitive email index (duplicate emails?): %v", err)
+	}
+}
+
 // TableName overrides
 func (User) TableName() string {
 	return "users"
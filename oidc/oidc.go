@@ -0,0 +1,264 @@
+// Log masuk OpenID Connect (authorization code + PKCE) untuk penyedia
+// identitas apa pun yang mendukung discovery (Google Workspace, Microsoft, dll).
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"jalurku/config"
+
+	"github.com/MicahParks/keyfunc/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown OIDC provider")
+	ErrInvalidIDToken  = errors.New("invalid ID token")
+)
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Penyedia identitas yang dikonfigurasi lewat OIDC_<NAMA>_*
+type Provider struct {
+	Name         string
+	DiscoveryURL string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	mu        sync.Mutex
+	discovery *discovery
+	jwks      *keyfunc.JWKS
+}
+
+// Isi dokumen /.well-known/openid-configuration yang dipakai
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Claim dari ID token yang dipakai untuk menautkan atau membuat pengguna
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+var (
+	providersMu sync.Mutex
+	providers   = map[string]*Provider{}
+)
+
+// Dapatkan penyedia dari nama, contoh "google" membaca OIDC_GOOGLE_DISCOVERY_URL,
+// OIDC_GOOGLE_CLIENT_ID, OIDC_GOOGLE_CLIENT_SECRET dan OIDC_GOOGLE_REDIRECT_URL.
+// Hanya nama yang ada di OIDC_PROVIDERS yang bisa dipakai.
+func Get(name string) (*Provider, error) {
+	name = strings.ToLower(name)
+	if !enabled(name) {
+		return nil, ErrUnknownProvider
+	}
+
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	if p, ok := providers[name]; ok {
+		return p, nil
+	}
+
+	prefix := "OIDC_" + strings.ToUpper(name) + "_"
+	p := &Provider{
+		Name:         name,
+		DiscoveryURL: config.Config(prefix + "DISCOVERY_URL"),
+		ClientID:     config.Config(prefix + "CLIENT_ID"),
+		ClientSecret: config.Config(prefix + "CLIENT_SECRET"),
+		RedirectURL:  config.Config(prefix + "REDIRECT_URL"),
+		Scopes:       strings.Fields(config.ConfigWithDefault(prefix+"SCOPES", "openid email profile")),
+	}
+	if p.DiscoveryURL == "" || p.ClientID == "" || p.RedirectURL == "" {
+		return nil, ErrUnknownProvider
+	}
+
+	providers[name] = p
+	return p, nil
+}
+
+func enabled(name string) bool {
+	for _, n := range strings.Split(config.Config("OIDC_PROVIDERS"), ",") {
+		if strings.ToLower(strings.TrimSpace(n)) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Memuat dokumen discovery dan JWKS sekali, lalu disimpan
+func (p *Provider) load(ctx context.Context) (*discovery, *keyfunc.JWKS, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, p.jwks, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.DiscoveryURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("discovery %s: status %d", p.DiscoveryURL, resp.StatusCode)
+	}
+
+	var d discovery
+	if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		return nil, nil, err
+	}
+	if d.Issuer == "" || d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, nil, fmt.Errorf("discovery %s: incomplete document", p.DiscoveryURL)
+	}
+
+	jwks, err := keyfunc.Get(d.JWKSURI, keyfunc.Options{
+		Client:            httpClient,
+		RefreshInterval:   time.Hour,
+		RefreshRateLimit:  5 * time.Minute,
+		RefreshTimeout:    10 * time.Second,
+		RefreshUnknownKID: true,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.discovery = &d
+	p.jwks = jwks
+	return p.discovery, p.jwks, nil
+}
+
+// Challenge PKCE (S256) dari code verifier
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// URL halaman log masuk penyedia identitas
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	d, _, err := p.load(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.ClientID)
+	q.Set("redirect_uri", p.RedirectURL)
+	q.Set("scope", strings.Join(p.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", Challenge(verifier))
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Menukar authorization code dengan ID token, lalu memverifikasinya
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	d, jwks, err := p.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.RedirectURL)
+	form.Set("client_id", p.ClientID)
+	form.Set("code_verifier", verifier)
+	if p.ClientSecret != "" {
+		form.Set("client_secret", p.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK || body.IDToken == "" {
+		return nil, fmt.Errorf("token endpoint: status %d %s", resp.StatusCode, body.Error)
+	}
+
+	return p.verify(body.IDToken, d, jwks, nonce)
+}
+
+// Memverifikasi tanda tangan, issuer, audience, masa berlaku dan nonce ID token
+func (p *Provider) verify(raw string, d *discovery, jwks *keyfunc.JWKS, nonce string) (*Identity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, jwks.Keyfunc,
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "PS256", "EdDSA"}),
+		jwt.WithIssuer(d.Issuer),
+		jwt.WithAudience(p.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if n, _ := claims["nonce"].(string); n != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	id := &Identity{}
+	id.Subject, _ = claims["sub"].(string)
+	id.Email, _ = claims["email"].(string)
+	id.Name, _ = claims["name"].(string)
+
+	// Beberapa penyedia mengirim email_verified sebagai string
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		id.EmailVerified = v
+	case string:
+		id.EmailVerified = v == "true"
+	}
+
+	if id.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+	return id, nil
+}
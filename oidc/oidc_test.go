@@ -0,0 +1,149 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"jalurku/oidc/oidctest"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestProvider(t *testing.T) (*oidctest.Server, *Provider) {
+	t.Helper()
+	srv, err := oidctest.NewServer("jalurku")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+
+	return srv, &Provider{
+		Name:         "mock",
+		DiscoveryURL: srv.DiscoveryURL(),
+		ClientID:     "jalurku",
+		RedirectURL:  "http://localhost:5173/auth/callback/mock",
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+}
+
+// Menjalankan log masuk lengkap: URL otorisasi, persetujuan di penyedia, lalu penukaran code
+func exchange(t *testing.T, srv *oidctest.Server, p *Provider, claims jwt.MapClaims, verifier, nonce string) (*Identity, error) {
+	t.Helper()
+	ctx := context.Background()
+
+	authURL, err := p.AuthCodeURL(ctx, "state", "nonce", "verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") != Challenge("verifier") {
+		t.Fatalf("authorization URL without S256 PKCE challenge: %s", authURL)
+	}
+	if q.Get("nonce") != "nonce" || q.Get("state") != "state" || q.Get("client_id") != "jalurku" {
+		t.Fatalf("authorization URL missing parameters: %s", authURL)
+	}
+
+	code := srv.Authorize(q.Get("code_challenge"), claims)
+	return p.Exchange(ctx, code, verifier, nonce)
+}
+
+func TestExchange(t *testing.T) {
+	srv, p := newTestProvider(t)
+
+	claims := srv.Claims("user-1")
+	claims["nonce"] = "nonce"
+	claims["email"] = "budi@sekolah.sch.id"
+	claims["email_verified"] = true
+	claims["name"] = "Budi"
+
+	id, err := exchange(t, srv, p, claims, "verifier", "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Subject != "user-1" || id.Email != "budi@sekolah.sch.id" || !id.EmailVerified || id.Name != "Budi" {
+		t.Fatalf("unexpected identity %+v", id)
+	}
+}
+
+func TestExchangeEmailVerifiedString(t *testing.T) {
+	srv, p := newTestProvider(t)
+
+	for _, v := range []string{"true", "false"} {
+		claims := srv.Claims("user-1")
+		claims["nonce"] = "nonce"
+		claims["email_verified"] = v
+
+		id, err := exchange(t, srv, p, claims, "verifier", "nonce")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id.EmailVerified != (v == "true") {
+			t.Fatalf("email_verified %q parsed as %v", v, id.EmailVerified)
+		}
+	}
+}
+
+func TestExchangePKCEMismatch(t *testing.T) {
+	srv, p := newTestProvider(t)
+
+	claims := srv.Claims("user-1")
+	claims["nonce"] = "nonce"
+
+	if _, err := exchange(t, srv, p, claims, "other-verifier", "nonce"); err == nil {
+		t.Fatal("exchange with wrong code_verifier succeeded")
+	}
+}
+
+func TestExchangeRejectsInvalidIDToken(t *testing.T) {
+	srv, p := newTestProvider(t)
+
+	tests := []struct {
+		name  string
+		edit  func(jwt.MapClaims)
+		nonce string
+	}{
+		{"nonce mismatch", func(c jwt.MapClaims) {}, "other-nonce"},
+		{"missing nonce", func(c jwt.MapClaims) { delete(c, "nonce") }, "nonce"},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, "nonce"},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "other-client" }, "nonce"},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, "nonce"},
+		{"missing exp", func(c jwt.MapClaims) { delete(c, "exp") }, "nonce"},
+		{"missing subject", func(c jwt.MapClaims) { delete(c, "sub") }, "nonce"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := srv.Claims("user-1")
+			claims["nonce"] = "nonce"
+			tt.edit(claims)
+
+			_, err := exchange(t, srv, p, claims, "verifier", tt.nonce)
+			if !errors.Is(err, ErrInvalidIDToken) {
+				t.Fatalf("got %v, want ErrInvalidIDToken", err)
+			}
+		})
+	}
+}
+
+func TestExchangeCodeSingleUse(t *testing.T) {
+	srv, p := newTestProvider(t)
+	ctx := context.Background()
+
+	claims := srv.Claims("user-1")
+	claims["nonce"] = "nonce"
+	code := srv.Authorize(Challenge("verifier"), claims)
+
+	if _, err := p.Exchange(ctx, code, "verifier", "nonce"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Exchange(ctx, code, "verifier", "nonce"); err == nil {
+		t.Fatal("authorization code was accepted twice")
+	}
+}
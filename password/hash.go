@@ -0,0 +1,166 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"jalurku/config"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Algoritma hash password yang didukung
+const (
+	AlgoArgon2id = "argon2id"
+	AlgoBcrypt   = "bcrypt"
+)
+
+var errInvalidHash = errors.New("invalid password hash")
+
+// Parameter hashing, diambil dari PASSWORD_HASH_* di konfigurasi
+type HashParams struct {
+	Algo string
+
+	// Argon2id (RFC 9106), default mengikuti rekomendasi OWASP
+	Memory  uint32 // KiB
+	Time    uint32
+	Threads uint8
+	KeyLen  uint32
+	SaltLen uint32
+
+	// bcrypt
+	Cost int
+}
+
+// Parameter hashing dari konfigurasi
+func CurrentParams() HashParams {
+	algo := config.ConfigWithDefault("PASSWORD_HASH_ALGO", AlgoArgon2id)
+	if algo != AlgoBcrypt {
+		algo = AlgoArgon2id
+	}
+
+	return HashParams{
+		Algo:    algo,
+		Memory:  uint32(config.IntWithDefault("PASSWORD_ARGON2_MEMORY", 19*1024)),
+		Time:    uint32(config.IntWithDefault("PASSWORD_ARGON2_TIME", 2)),
+		Threads: uint8(config.IntWithDefault("PASSWORD_ARGON2_THREADS", 1)),
+		KeyLen:  32,
+		SaltLen: 16,
+		Cost:    config.IntWithDefault("PASSWORD_BCRYPT_COST", 10),
+	}
+}
+
+// Hash password dengan algoritma dan parameter dari konfigurasi.
+// Hasilnya menyimpan algoritma dan parameternya, contoh:
+//
+//	$argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>
+//	$2a$10$<salt+hash>
+func Hash(password string) (string, error) {
+	return CurrentParams().Hash(password)
+}
+
+func (p HashParams) Hash(password string) (string, error) {
+	if p.Algo == AlgoBcrypt {
+		b, err := bcrypt.GenerateFromPassword([]byte(password), p.Cost)
+		return string(b), err
+	}
+
+	salt := make([]byte, p.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+var (
+	dummyOnce sync.Once
+	dummyHash string
+)
+
+// Membandingkan password dengan hash. needsRehash bernilai true jika password benar
+// tapi hash memakai algoritma atau parameter yang berbeda dari konfigurasi sekarang.
+// Hash kosong tetap dihitung dengan hash palsu agar waktunya sama (menghindari timing attack).
+func Verify(password, encoded string) (ok bool, needsRehash bool) {
+	if encoded == "" {
+		dummyOnce.Do(func() { dummyHash, _ = Hash("jalurku-dummy-password") })
+		verify(password, dummyHash)
+		return false, false
+	}
+
+	current := CurrentParams()
+	stored, ok := verify(password, encoded)
+	if !ok {
+		return false, false
+	}
+
+	if stored.Algo != current.Algo {
+		return true, true
+	}
+	if stored.Algo == AlgoBcrypt {
+		return true, stored.Cost != current.Cost
+	}
+	return true, stored.Memory != current.Memory ||
+		stored.Time != current.Time ||
+		stored.Threads != current.Threads ||
+		stored.KeyLen != current.KeyLen
+}
+
+func verify(password, encoded string) (HashParams, bool) {
+	if strings.HasPrefix(encoded, "$argon2id$") {
+		p, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return p, false
+		}
+		other := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+		return p, subtle.ConstantTimeCompare(key, other) == 1
+	}
+
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return HashParams{}, false
+	}
+	p := HashParams{Algo: AlgoBcrypt, Cost: cost}
+	return p, bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)) == nil
+}
+
+func decodeArgon2id(encoded string) (HashParams, []byte, []byte, error) {
+	p := HashParams{Algo: AlgoArgon2id}
+
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, errInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, errInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, errInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, errInvalidHash
+	}
+
+	p.SaltLen = uint32(len(salt))
+	p.KeyLen = uint32(len(key))
+	return p, salt, key, nil
+}
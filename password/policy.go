@@ -0,0 +1,134 @@
+// Kebijakan dan hashing password pengguna
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"jalurku/config"
+)
+
+// Daftar password umum yang selalu ditolak
+//
+//go:embed common.txt
+var commonPasswords string
+
+var (
+	denylistOnce sync.Once
+	denylist     map[string]bool
+)
+
+// Aturan password, diambil dari PASSWORD_* di konfigurasi
+type Policy struct {
+	MinLength     int
+	MaxLength     int
+	RequireLower  bool
+	RequireUpper  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	CheckDenylist bool
+}
+
+// Kebijakan password dari konfigurasi
+func CurrentPolicy() Policy {
+	return Policy{
+		MinLength:     config.IntWithDefault("PASSWORD_MIN_LENGTH", 8),
+		MaxLength:     config.IntWithDefault("PASSWORD_MAX_LENGTH", 72),
+		RequireLower:  config.BoolWithDefault("PASSWORD_REQUIRE_LOWER", false),
+		RequireUpper:  config.BoolWithDefault("PASSWORD_REQUIRE_UPPER", false),
+		RequireDigit:  config.BoolWithDefault("PASSWORD_REQUIRE_DIGIT", false),
+		RequireSymbol: config.BoolWithDefault("PASSWORD_REQUIRE_SYMBOL", false),
+		CheckDenylist: config.BoolWithDefault("PASSWORD_DENYLIST", true),
+	}
+}
+
+// Memvalidasi password dengan kebijakan dari konfigurasi.
+// Pesan error bisa langsung ditampilkan ke pengguna.
+func Validate(password string) error {
+	return CurrentPolicy().Validate(password)
+}
+
+func (p Policy) Validate(password string) error {
+	length := len([]rune(password))
+	if length < p.MinLength {
+		return fmt.Errorf("Password must be at least %d characters", p.MinLength)
+	}
+	// Dihitung dalam byte, bcrypt hanya memakai 72 byte pertama
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		return fmt.Errorf("Password is too long (max %d bytes)", p.MaxLength)
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	if p.RequireLower && !lower {
+		return errors.New("Password must contain a lowercase letter")
+	}
+	if p.RequireUpper && !upper {
+		return errors.New("Password must contain an uppercase letter")
+	}
+	if p.RequireDigit && !digit {
+		return errors.New("Password must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		return errors.New("Password must contain a symbol")
+	}
+
+	if p.CheckDenylist && isCommon(password) {
+		return errors.New("Password is too common")
+	}
+	return nil
+}
+
+// Apakah password ada di daftar password umum (bawaan dan PASSWORD_DENYLIST_FILE)?
+func isCommon(password string) bool {
+	denylistOnce.Do(loadDenylist)
+	return denylist[strings.ToLower(password)]
+}
+
+func loadDenylist() {
+	denylist = map[string]bool{}
+	addDenylist(strings.NewReader(commonPasswords))
+
+	path := config.Config("PASSWORD_DENYLIST_FILE")
+	if path == "" {
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		log.Printf("⚠️ Gagal membuka PASSWORD_DENYLIST_FILE: %v", err)
+		return
+	}
+	defer f.Close()
+	addDenylist(f)
+}
+
+func addDenylist(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if line != "" && !strings.HasPrefix(line, "#") {
+			denylist[line] = true
+		}
+	}
+}
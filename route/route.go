@@ -23,6 +23,9 @@ func SetupRoutes(app *fiber.App) {
 		},
 	}))
 
+	// Kunci publik JWT untuk layanan lain (tanpa API key)
+	app.Get("/.well-known/jwks.json", controller.JWKS)
+
 	// API Group
 	api := app.Group("/api")
 	api.Use(limiter.New(limiter.Config{
@@ -33,12 +36,14 @@ func SetupRoutes(app *fiber.App) {
 			return c.SendStatus(fiber.StatusTooManyRequests)
 		},
 	}))
+	// Semua klien harus mengirim X-API-Key
+	api.Use(middleware.APIKey())
 
 	// Health check
 	api.Get("/", controller.Hello)
 
 	// Auth routes (public)
-	auth := api.Group("/auth")
+	auth := api.Group("/auth", middleware.RequireScope("auth"))
 	auth.Use(limiter.New(limiter.Config{
 		Max:        10,
 		Expiration: 1 * time.Minute,
@@ -49,9 +54,40 @@ func SetupRoutes(app *fiber.App) {
 	}))
 	auth.Post("/login", controller.Login)
 	auth.Post("/register", controller.Register)
+	auth.Post("/refresh", middleware.CSRF(), controller.Refresh)
+	auth.Post("/logout", middleware.Protected(), controller.Logout)
+	auth.Post("/forgot", controller.ForgotPassword)
+	auth.Post("/reset", controller.ResetPassword)
+	auth.Post("/mfa", controller.LoginMFA)
+	auth.Get("/oidc/:provider", controller.OIDCStart)
+	auth.Post("/oidc/:provider/callback", controller.OIDCCallback)
+	auth.Post("/magic-link", limiter.New(limiter.Config{
+		Max:        5,
+		Expiration: 15 * time.Minute,
+		Storage:    database.RedisStore(),
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return "magic_link_ip:" + c.IP()
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusTooManyRequests)
+		},
+	}), controller.RequestMagicLink)
+	auth.Post("/magic-link/verify", controller.RedeemMagicLink)
+	auth.Post("/verify-email", controller.VerifyEmail)
+	auth.Post("/verify-email/resend", limiter.New(limiter.Config{
+		Max:        3,
+		Expiration: 15 * time.Minute,
+		Storage:    database.RedisStore(),
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return "verify_resend_ip:" + c.IP()
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusTooManyRequests)
+		},
+	}), controller.ResendVerification)
 
 	// User routes
-	user := api.Group("/user")
+	user := api.Group("/user", middleware.RequireScope("user"))
 	user.Use(limiter.New(limiter.Config{
 		Max:        80,
 		Expiration: 1 * time.Minute,
@@ -63,17 +99,28 @@ func SetupRoutes(app *fiber.App) {
 	user.Get("/:id", controller.GetUser)
 	// Hanya pengguna terautentikasi
 	user.Get("/me", middleware.Protected(), controller.GetCurrentUser)
+	user.Get("/me/sessions", middleware.Protected(), controller.GetSessions)
+	user.Delete("/me/sessions", middleware.Protected(), controller.RevokeOtherSessions)
+	user.Delete("/me/sessions/:id", middleware.Protected(), controller.RevokeSession)
+	user.Post("/me/mfa/setup", middleware.Protected(), controller.SetupMFA)
+	user.Post("/me/mfa/enable", middleware.Protected(), controller.EnableMFA)
+	user.Post("/me/mfa/disable", middleware.Protected(), controller.DisableMFA)
+	user.Post("/me/mfa/recovery-codes", middleware.Protected(), controller.RegenerateRecoveryCodes)
 	user.Put("/:id", middleware.Protected(), controller.UpdateUser)
+	user.Put("/:id/password", middleware.Protected(), controller.ChangePassword)
 	user.Delete("/:id", middleware.Protected(), controller.DeleteUser)
 
-	angket := api.Group("/angket")
+	angket := api.Group("/angket", middleware.RequireScope("angket"))
 	angket.Use(middleware.Optional())
 	angket.Post("/mulai", controller.StartAngket)
 	angket.Post("/submit", controller.SubmitJawaban)
+	angket.Post("/submit-batch", controller.SubmitJawabanBatch)
 	angket.Post("/selesai", controller.FinishAngket)
+	angket.Get("/aktif", controller.GetAngketAktif)
+	angket.Get("/:session_id/progress", controller.GetAngketProgress)
 
 	// Rute Pertanyaan
-	pertanyaan := api.Group("/pertanyaan")
+	pertanyaan := api.Group("/pertanyaan", middleware.RequireScope("pertanyaan"))
 	pertanyaan.Use(limiter.New(limiter.Config{
 		Max:        80,
 		Expiration: 1 * time.Minute,
@@ -83,14 +130,31 @@ func SetupRoutes(app *fiber.App) {
 		},
 	}))
 	pertanyaan.Get("/", controller.GetPertanyaans)
+	pertanyaan.Get("/skala", controller.GetSkala)
 	pertanyaan.Get("/:id", controller.GetPertanyaanByID)
-	// Hanya Admin
-	pertanyaan.Post("/", middleware.Protected(), middleware.AdminOnly(), controller.CreatePertanyaan)
-	pertanyaan.Put("/:id", middleware.Protected(), middleware.AdminOnly(), controller.UpdatePertanyaan)
-	pertanyaan.Delete("/:id", middleware.Protected(), middleware.AdminOnly(), controller.DeletePertanyaan)
+	// Hanya role dengan permission pertanyaan:write
+	pertanyaan.Post("/", middleware.Protected(), middleware.RequirePermission("pertanyaan:write"), controller.CreatePertanyaan)
+	pertanyaan.Post("/skala", middleware.Protected(), middleware.RequirePermission("pertanyaan:write"), controller.CreateSkala)
+	pertanyaan.Put("/:id", middleware.Protected(), middleware.RequirePermission("pertanyaan:write"), controller.UpdatePertanyaan)
+	pertanyaan.Delete("/:id", middleware.Protected(), middleware.RequirePermission("pertanyaan:write"), controller.DeletePertanyaan)
+	pertanyaan.Get("/:id/bobot", middleware.Protected(), middleware.RequirePermission("pertanyaan:write"), controller.GetBobot)
+	pertanyaan.Put("/:id/bobot/:jurusan_id", middleware.Protected(), middleware.RequirePermission("pertanyaan:write"), controller.SetBobot)
+	pertanyaan.Delete("/:id/bobot/:jurusan_id", middleware.Protected(), middleware.RequirePermission("pertanyaan:write"), controller.DeleteBobot)
 
-	// Admin routes (protected + admin only)
-	admin := api.Group("/admin", middleware.Protected(), middleware.AdminOnly())
+	// Hasil angket (guru BK, wali kelas, admin)
+	hasil := api.Group("/hasil", middleware.RequireScope("hasil"), middleware.Protected(), middleware.RequirePermission("hasil:read"))
+	hasil.Use(limiter.New(limiter.Config{
+		Max:        80,
+		Expiration: 1 * time.Minute,
+		Storage:    database.RedisStore(),
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusTooManyRequests)
+		},
+	}))
+	hasil.Get("/", controller.GetHasilAngket)
+
+	// Admin routes (protected, permission dicek per rute)
+	admin := api.Group("/admin", middleware.RequireScope("admin"), middleware.Protected())
 	admin.Use(limiter.New(limiter.Config{
 		Max:        80,
 		Expiration: 1 * time.Minute,
@@ -100,4 +164,29 @@ func SetupRoutes(app *fiber.App) {
 		},
 	}))
 	// admin.Get("/dashboard", controller.GetAdminDashboard)                    // Admin dashboard
+
+	// Manajemen pengguna
+	admin.Get("/users", middleware.RequirePermission("user:manage"), controller.GetUsers)
+	admin.Post("/users/:id/disable", middleware.RequirePermission("user:manage"), controller.DisableUser)
+	admin.Post("/users/:id/enable", middleware.RequirePermission("user:manage"), controller.EnableUser)
+	admin.Post("/users/:id/force-password-reset", middleware.RequirePermission("user:manage"), controller.ForcePasswordReset)
+	admin.Post("/users/:id/restore", middleware.RequirePermission("user:manage"), controller.RestoreUser)
+	admin.Delete("/users/:id/purge", middleware.RequirePermission("user:manage"), controller.PurgeUser)
+	admin.Post("/users/:id/revoke-tokens", middleware.RequirePermission("user:manage"), controller.RevokeUserTokens)
+	admin.Post("/users/:id/unlock", middleware.RequirePermission("user:manage"), controller.UnlockUser)
+
+	// Impersonasi (superadmin), tercatat di audit log
+	admin.Post("/users/:id/impersonate", middleware.RequirePermission("user:impersonate"), controller.Impersonate)
+	admin.Get("/audit-logs", middleware.RequirePermission("user:impersonate"), controller.GetAuditLogs)
+
+	// Role pengguna
+	admin.Get("/roles", middleware.RequirePermission("role:manage"), controller.GetRoles)
+	admin.Put("/users/:id/role", middleware.RequirePermission("role:manage"), controller.AssignRole)
+
+	// Klien dan API key
+	admin.Post("/clients", middleware.RequirePermission("client:manage"), controller.CreateClient)
+	admin.Get("/clients", middleware.RequirePermission("client:manage"), controller.GetClients)
+	admin.Post("/clients/:id/keys", middleware.RequirePermission("client:manage"), controller.CreateAPIKey)
+	admin.Post("/api-keys/:id/rotate", middleware.RequirePermission("client:manage"), controller.RotateAPIKey)
+	admin.Delete("/api-keys/:id", middleware.RequirePermission("client:manage"), controller.RevokeAPIKey)
 }
\ No newline at end of file
@@ -0,0 +1,345 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"jalurku/config"
+	"jalurku/database"
+	"jalurku/model"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Nomor advisory lock PostgreSQL agar hanya satu instance yang merotasi kunci
+const keyRotationLock = 720901
+
+var (
+	ErrUnknownKey = errors.New("unknown signing key")
+	ErrNoKey      = errors.New("no active signing key")
+)
+
+// Kunci yang sudah di-parse dan siap dipakai
+type signingKey struct {
+	model.SigningKey
+	method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+var keys struct {
+	sync.RWMutex
+	list []*signingKey
+}
+
+// Algoritma penandatangan dari JWT_ALG: RS256 (default) atau EdDSA
+func signingAlg() string {
+	if config.Config("JWT_ALG") == "EdDSA" {
+		return "EdDSA"
+	}
+	return "RS256"
+}
+
+// Issuer (claim iss) dari token yang diterbitkan, diambil dari JWT_ISSUER
+func Issuer() string {
+	return config.ConfigWithDefault("JWT_ISSUER", "jalurku")
+}
+
+// Memuat kunci dari database, membuat kunci pertama jika belum ada,
+// lalu menjalankan rotasi terjadwal di latar belakang
+func InitKeys() error {
+	if err := rotateKeys(); err != nil {
+		return err
+	}
+	if err := loadKeys(); err != nil {
+		return err
+	}
+
+	interval := config.DurationWithDefault("JWT_KEY_CHECK_INTERVAL", 5*time.Minute)
+	go func() {
+		for range time.Tick(interval) {
+			if err := rotateKeys(); err != nil {
+				log.Printf("⚠️ Gagal merotasi kunci JWT: %v", err)
+			}
+			if err := loadKeys(); err != nil {
+				log.Printf("⚠️ Gagal memuat kunci JWT: %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// Memastikan selalu ada kunci aktif, dan kunci berikutnya sudah dipublikasikan
+// di JWKS (JWT_KEY_PREPUBLISH) sebelum mulai dipakai menandatangani
+func rotateKeys() error {
+	lifetime := config.DurationWithDefault("JWT_KEY_ROTATION", 720*time.Hour)
+	prepublish := config.DurationWithDefault("JWT_KEY_PREPUBLISH", time.Hour)
+
+	// Kunci lama harus tetap bisa memverifikasi token terakhir yang ditandatanganinya
+	overlap := config.DurationWithDefault("JWT_KEY_OVERLAP", 24*time.Hour)
+	if overlap < AccessTTL() {
+		overlap = AccessTTL()
+	}
+
+	alg := signingAlg()
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		// Advisory lock hanya ada di PostgreSQL (database lain dipakai untuk pengujian)
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", keyRotationLock).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		if err := tx.Where("expires_at < ?", now).Delete(&model.SigningKey{}).Error; err != nil {
+			return err
+		}
+
+		var list []model.SigningKey
+		if err := tx.Where("retires_at > ?", now).Order("activates_at").Find(&list).Error; err != nil {
+			return err
+		}
+
+		var active, next *model.SigningKey
+		for i := range list {
+			k := &list[i]
+			if !k.ActivatesAt.After(now) {
+				active = k
+			} else if next == nil {
+				next = k
+			}
+		}
+
+		// JWT_ALG berubah: kunci lama berhenti menandatangani, tapi tetap bisa memverifikasi
+		if active != nil && active.Alg != alg {
+			if err := tx.Model(active).Update("retires_at", now).Error; err != nil {
+				return err
+			}
+			active = nil
+		}
+		if next != nil && next.Alg != alg {
+			if err := tx.Delete(next).Error; err != nil {
+				return err
+			}
+			next = nil
+		}
+
+		if active == nil {
+			if next != nil {
+				if err := tx.Delete(next).Error; err != nil {
+					return err
+				}
+				next = nil
+			}
+			k, err := generateKey(alg, now, lifetime, overlap)
+			if err != nil {
+				return err
+			}
+			log.Printf("🔑 Kunci JWT baru %s (%s) aktif", k.Kid, k.Alg)
+			return tx.Create(k).Error
+		}
+
+		if next == nil && active.RetiresAt.Sub(now) <= prepublish {
+			k, err := generateKey(alg, active.RetiresAt, lifetime, overlap)
+			if err != nil {
+				return err
+			}
+			log.Printf("🔑 Kunci JWT %s (%s) dipublikasikan, aktif pada %s", k.Kid, k.Alg, k.ActivatesAt.Format(time.RFC3339))
+			return tx.Create(k).Error
+		}
+		return nil
+	})
+}
+
+func generateKey(alg string, activatesAt time.Time, lifetime, overlap time.Duration) (*model.SigningKey, error) {
+	var private crypto.Signer
+	var err error
+	switch alg {
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	retiresAt := activatesAt.Add(lifetime)
+	return &model.SigningKey{
+		Kid:         uuid.New().String(),
+		Alg:         alg,
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		ActivatesAt: activatesAt,
+		RetiresAt:   retiresAt,
+		ExpiresAt:   retiresAt.Add(overlap),
+	}, nil
+}
+
+// Memuat semua kunci yang masih berlaku dari database
+func loadKeys() error {
+	var rows []model.SigningKey
+	if err := database.DB.Where("expires_at > ?", time.Now()).Order("activates_at").Find(&rows).Error; err != nil {
+		return err
+	}
+
+	list := make([]*signingKey, 0, len(rows))
+	for _, row := range rows {
+		k, err := parseKey(row)
+		if err != nil {
+			log.Printf("⚠️ Kunci JWT %s tidak valid: %v", row.Kid, err)
+			continue
+		}
+		list = append(list, k)
+	}
+
+	keys.Lock()
+	keys.list = list
+	keys.Unlock()
+	return nil
+}
+
+func parseKey(row model.SigningKey) (*signingKey, error) {
+	block, _ := pem.Decode([]byte(row.PrivateKey))
+	if block == nil {
+		return nil, errors.New("invalid PEM")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &signingKey{SigningKey: row}
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		k.method, k.private = jwt.SigningMethodRS256, key
+	case ed25519.PrivateKey:
+		k.method, k.private = jwt.SigningMethodEdDSA, key
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	if k.method.Alg() != row.Alg {
+		return nil, fmt.Errorf("key type does not match alg %s", row.Alg)
+	}
+	return k, nil
+}
+
+// Kunci yang dipakai untuk menandatangani saat ini
+func currentKey() (*signingKey, error) {
+	keys.RLock()
+	defer keys.RUnlock()
+
+	now := time.Now()
+	var current *signingKey
+	for _, k := range keys.list {
+		if !k.ActivatesAt.After(now) && k.RetiresAt.After(now) {
+			current = k
+		}
+	}
+	if current == nil {
+		return nil, ErrNoKey
+	}
+	return current, nil
+}
+
+// Menandatangani token dengan kunci aktif, header kid menunjuk ke kunci di JWKS
+func sign(claims jwt.MapClaims) (string, error) {
+	k, err := currentKey()
+	if err != nil {
+		return "", err
+	}
+
+	t := jwt.NewWithClaims(k.method, claims)
+	t.Header["kid"] = k.Kid
+	return t.SignedString(k.private)
+}
+
+// Keyfunc untuk memverifikasi token: kunci dicari dari kid,
+// dan algoritma di header harus sama dengan algoritma kunci
+func Keyfunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+
+	keys.RLock()
+	defer keys.RUnlock()
+
+	now := time.Now()
+	for _, k := range keys.list {
+		if k.Kid != kid {
+			continue
+		}
+		if !k.ExpiresAt.After(now) {
+			break
+		}
+		if t.Method.Alg() != k.method.Alg() {
+			return nil, jwt.ErrTokenSignatureInvalid
+		}
+		return k.private.Public(), nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// Parse dan verifikasi access token (tanda tangan, algoritma, issuer, masa berlaku)
+func Parse(raw string) (*jwt.Token, error) {
+	return jwt.Parse(raw, Keyfunc,
+		jwt.WithValidMethods([]string{"RS256", "EdDSA"}),
+		jwt.WithIssuer(Issuer()),
+		jwt.WithExpirationRequired(),
+	)
+}
+
+// Kunci publik yang masih berlaku dalam format JWK Set (RFC 7517)
+func JWKS() map[string]interface{} {
+	keys.RLock()
+	defer keys.RUnlock()
+
+	now := time.Now()
+	sorted := make([]*signingKey, 0, len(keys.list))
+	for _, k := range keys.list {
+		if k.ExpiresAt.After(now) {
+			sorted = append(sorted, k)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ActivatesAt.After(sorted[j].ActivatesAt) })
+
+	list := make([]map[string]string, 0, len(sorted))
+	for _, k := range sorted {
+		jwk := map[string]string{
+			"kid": k.Kid,
+			"alg": k.method.Alg(),
+			"use": "sig",
+		}
+		switch pub := k.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk["kty"] = "OKP"
+			jwk["crv"] = "Ed25519"
+			jwk["x"] = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		list = append(list, jwk)
+	}
+
+	return map[string]interface{}{"keys": list}
+}
@@ -0,0 +1,76 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"jalurku/database"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Key Redis untuk token sekali pakai (reset password, dll).
+//
+//	onetime:<purpose>:<hash>         -> user_id pemilik token
+//	onetime_user:<purpose>:<user_id> -> hash token terakhir milik pengguna
+const (
+	oneTimePrefix     = "onetime:"
+	oneTimeUserPrefix = "onetime_user:"
+)
+
+// Keperluan token sekali pakai
+const (
+	PurposePasswordReset = "password_reset"
+	PurposeEmailVerify   = "email_verify"
+	PurposeMFALogin      = "mfa_login"
+	PurposeMagicLink     = "magic_link"
+)
+
+var ErrInvalidOneTime = errors.New("invalid or expired token")
+
+// Membuat token sekali pakai untuk pengguna.
+// Token sebelumnya dengan keperluan yang sama otomatis tidak berlaku.
+func IssueOneTime(purpose, userID string, ttl time.Duration) (string, error) {
+	ctx := context.Background()
+	rdb := database.RedisClient
+
+	raw, err := Random()
+	if err != nil {
+		return "", err
+	}
+
+	userKey := oneTimeUserPrefix + purpose + ":" + userID
+	old, err := rdb.Get(ctx, userKey).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return "", err
+	}
+
+	pipe := rdb.TxPipeline()
+	if old != "" {
+		pipe.Del(ctx, oneTimePrefix+purpose+":"+old)
+	}
+	pipe.Set(ctx, oneTimePrefix+purpose+":"+Hash(raw), userID, ttl)
+	pipe.Set(ctx, userKey, Hash(raw), ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// Memakai token sekali pakai dan mengembalikan user_id pemiliknya
+func ConsumeOneTime(purpose, raw string) (string, error) {
+	ctx := context.Background()
+	rdb := database.RedisClient
+
+	userID, err := rdb.GetDel(ctx, oneTimePrefix+purpose+":"+Hash(raw)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrInvalidOneTime
+	}
+	if err != nil {
+		return "", err
+	}
+
+	rdb.Del(ctx, oneTimeUserPrefix+purpose+":"+userID)
+	return userID, nil
+}
@@ -0,0 +1,154 @@
+package token
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"jalurku/config"
+	"jalurku/database"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Key Redis untuk refresh token.
+//
+//	refresh:<hash>         -> data refresh token yang masih aktif
+//	refresh_used:<hash>    -> family dari refresh token yang sudah dipakai
+//	refresh_family:<id>    -> user_id, ada selama family belum dicabut
+//	refresh_user:<user_id> -> kumpulan family milik pengguna
+const (
+	refreshPrefix     = "refresh:"
+	refreshUsedPrefix = "refresh_used:"
+	familyPrefix      = "refresh_family:"
+	userFamilyPrefix  = "refresh_user:"
+)
+
+var (
+	ErrInvalidRefresh = errors.New("invalid or expired refresh token")
+	ErrRefreshReused  = errors.New("refresh token reuse detected")
+)
+
+type refreshRecord struct {
+	Grant
+	FamilyID string `json:"family_id"`
+}
+
+// Masa berlaku refresh token, diambil dari REFRESH_EXPIRE (default 30 hari)
+func RefreshTTL() time.Duration {
+	return config.DurationWithDefault("REFRESH_EXPIRE", 720*time.Hour)
+}
+
+// Hash SHA-256 dari token, hanya hash yang disimpan di Redis
+func Hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// Membuat string acak yang aman untuk dipakai sebagai token
+func Random() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Membuat refresh token pertama dari sesi log masuk (family dibuat oleh StartSession)
+func IssueRefresh(grant Grant) (string, error) {
+	return storeRefresh(context.Background(), refreshRecord{Grant: grant, FamilyID: grant.SessionID})
+}
+
+func storeRefresh(ctx context.Context, rec refreshRecord) (string, error) {
+	raw, err := Random()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+
+	if err := database.RedisClient.Set(ctx, refreshPrefix+Hash(raw), data, RefreshTTL()).Err(); err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// Menukar refresh token dengan yang baru (rotasi).
+// Refresh token yang sudah pernah dipakai akan mencabut seluruh family-nya.
+func RotateRefresh(raw string) (grant Grant, newRaw string, err error) {
+	ctx := context.Background()
+	rdb := database.RedisClient
+	hash := Hash(raw)
+
+	// GETDEL memastikan satu refresh token hanya bisa dipakai sekali
+	data, err := rdb.GetDel(ctx, refreshPrefix+hash).Result()
+	if errors.Is(err, redis.Nil) {
+		familyID, usedErr := rdb.Get(ctx, refreshUsedPrefix+hash).Result()
+		if usedErr == nil {
+			if err := RevokeFamily(familyID); err != nil {
+				return Grant{}, "", err
+			}
+			return Grant{}, "", ErrRefreshReused
+		}
+		return Grant{}, "", ErrInvalidRefresh
+	}
+	if err != nil {
+		return Grant{}, "", err
+	}
+
+	var rec refreshRecord
+	if err := json.Unmarshal([]byte(data), &rec); err != nil {
+		return Grant{}, "", ErrInvalidRefresh
+	}
+	// Refresh token lama belum menyimpan ID sesi
+	if rec.SessionID == "" {
+		rec.SessionID = rec.FamilyID
+	}
+
+	ttl := RefreshTTL()
+	if err := rdb.Set(ctx, refreshUsedPrefix+hash, rec.FamilyID, ttl).Err(); err != nil {
+		return Grant{}, "", err
+	}
+
+	// Family yang sudah dicabut tidak boleh diperpanjang
+	ok, err := rdb.Expire(ctx, familyPrefix+rec.FamilyID, ttl).Result()
+	if err != nil {
+		return Grant{}, "", err
+	}
+	if !ok {
+		return Grant{}, "", ErrInvalidRefresh
+	}
+	rdb.Expire(ctx, sessionPrefix+rec.FamilyID, ttl)
+
+	newRaw, err = storeRefresh(ctx, rec)
+	if err != nil {
+		return Grant{}, "", err
+	}
+	return rec.Grant, newRaw, nil
+}
+
+// Mencabut satu family refresh token (sekaligus sesinya)
+func RevokeFamily(familyID string) error {
+	ctx := context.Background()
+	rdb := database.RedisClient
+
+	userID, err := rdb.GetDel(ctx, familyPrefix+familyID).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := rdb.Del(ctx, sessionPrefix+familyID).Err(); err != nil {
+		return err
+	}
+	return rdb.SRem(ctx, userFamilyPrefix+userID, familyID).Err()
+}
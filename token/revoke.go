@@ -0,0 +1,132 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"jalurku/database"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// Key Redis untuk daftar pencabutan access token.
+//
+//	jwt_denylist:<jti>          -> access token yang sudah dicabut
+//	jwt_revoked_user:<user_id>  -> waktu (unix milidetik) pencabutan semua token pengguna
+const (
+	denylistPrefix    = "jwt_denylist:"
+	revokedUserPrefix = "jwt_revoked_user:"
+)
+
+// Mencabut satu access token sampai waktu kedaluwarsanya
+func Revoke(claims jwt.MapClaims) error {
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return nil
+	}
+
+	ttl := AccessTTL()
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		ttl = time.Until(exp.Time)
+	}
+	if ttl <= 0 {
+		return nil
+	}
+
+	return database.RedisClient.Set(context.Background(), denylistPrefix+jti, 1, ttl).Err()
+}
+
+// Mencabut semua access token dan refresh token milik pengguna
+func RevokeUser(userID string) error {
+	ctx := context.Background()
+	rdb := database.RedisClient
+
+	// Semua access token yang diterbitkan sebelum atau pada milidetik ini dianggap dicabut
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	if err := rdb.Set(ctx, revokedUserPrefix+userID, now, AccessTTL()).Err(); err != nil {
+		return err
+	}
+
+	families, err := rdb.SMembers(ctx, userFamilyPrefix+userID).Result()
+	if err != nil {
+		return err
+	}
+	for _, familyID := range families {
+		if err := RevokeFamily(familyID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Mencabut family dari sebuah refresh token (saat log keluar)
+func RevokeRefresh(raw string) error {
+	data, err := database.RedisClient.Get(context.Background(), refreshPrefix+Hash(raw)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var rec refreshRecord
+	if err := json.Unmarshal([]byte(data), &rec); err != nil {
+		return nil
+	}
+	return RevokeFamily(rec.FamilyID)
+}
+
+// Apakah access token sudah dicabut (termasuk jika sesinya sudah dicabut)?
+// Jika Redis gagal diakses, token dianggap dicabut.
+func IsRevoked(claims jwt.MapClaims) bool {
+	ctx := context.Background()
+	rdb := database.RedisClient
+
+	jti, _ := claims["jti"].(string)
+	userID, _ := claims["user_id"].(string)
+	sid, _ := claims["sid"].(string)
+	if jti == "" || userID == "" || sid == "" {
+		return true
+	}
+
+	denied, err := rdb.Exists(ctx, denylistPrefix+jti).Result()
+	if err != nil || denied > 0 {
+		return true
+	}
+
+	if active, err := SessionActive(sid); err != nil || !active {
+		return true
+	}
+
+	// Token impersonasi juga dicabut jika token milik admin-nya dicabut
+	if revokedBefore(ctx, claims, userID) {
+		return true
+	}
+	if impersonatorID, _ := claims["impersonator_id"].(string); impersonatorID != "" {
+		return revokedBefore(ctx, claims, impersonatorID)
+	}
+	return false
+}
+
+// Apakah token diterbitkan sebelum semua token pengguna dicabut?
+func revokedBefore(ctx context.Context, claims jwt.MapClaims, userID string) bool {
+	revokedAt, err := database.RedisClient.Get(ctx, revokedUserPrefix+userID).Int64()
+	if errors.Is(err, redis.Nil) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+
+	// Dibaca langsung dari claim, GetIssuedAt membulatkan ke detik
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		return true
+	}
+	return int64(math.Round(iat*1000)) <= revokedAt
+}
@@ -0,0 +1,148 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"jalurku/database"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Key Redis untuk sesi log masuk. Satu sesi sama dengan satu family refresh token,
+// ID sesi adalah ID family.
+//
+//	login_session:<id>       -> hash user_agent, ip, created_at, last_seen_at
+//	login_session_seen:<id>  -> penanda agar last_seen_at tidak ditulis setiap request
+const (
+	sessionPrefix     = "login_session:"
+	sessionSeenPrefix = "login_session_seen:"
+	sessionSeenEvery  = time.Minute
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Sesi log masuk pengguna
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+// Mencatat sesi baru saat log masuk, mengembalikan ID sesi
+func StartSession(userID, userAgent, ip string) (string, error) {
+	ctx := context.Background()
+	ttl := RefreshTTL()
+	id := uuid.New().String()
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	userKey := userFamilyPrefix + userID
+
+	pipe := database.RedisClient.TxPipeline()
+	pipe.Set(ctx, familyPrefix+id, userID, ttl)
+	pipe.SAdd(ctx, userKey, id)
+	pipe.Expire(ctx, userKey, ttl)
+	pipe.HSet(ctx, sessionPrefix+id,
+		"user_agent", userAgent,
+		"ip", ip,
+		"created_at", now,
+		"last_seen_at", now,
+	)
+	pipe.Expire(ctx, sessionPrefix+id, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Memperbarui waktu terakhir sesi dipakai (paling sering sekali per menit)
+func TouchSession(id, ip string) {
+	ctx := context.Background()
+	rdb := database.RedisClient
+
+	ok, err := rdb.SetNX(ctx, sessionSeenPrefix+id, 1, sessionSeenEvery).Result()
+	if err != nil || !ok {
+		return
+	}
+
+	// HSet hanya jika sesi masih ada, agar sesi yang dicabut tidak muncul lagi
+	if n, err := rdb.Exists(ctx, sessionPrefix+id).Result(); err != nil || n == 0 {
+		return
+	}
+	rdb.HSet(ctx, sessionPrefix+id,
+		"ip", ip,
+		"last_seen_at", strconv.FormatInt(time.Now().Unix(), 10),
+	)
+}
+
+// Apakah sesi masih aktif (belum log keluar atau dicabut)?
+func SessionActive(id string) (bool, error) {
+	n, err := database.RedisClient.Exists(context.Background(), familyPrefix+id).Result()
+	return n > 0, err
+}
+
+// Daftar sesi aktif milik pengguna, terbaru di atas
+func ListSessions(userID string) ([]Session, error) {
+	ctx := context.Background()
+	rdb := database.RedisClient
+
+	ids, err := rdb.SMembers(ctx, userFamilyPrefix+userID).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []Session{}
+	for _, id := range ids {
+		data, err := rdb.HGetAll(ctx, sessionPrefix+id).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(data) == 0 {
+			// Family tanpa data sesi (sudah kedaluwarsa)
+			active, err := SessionActive(id)
+			if err != nil {
+				return nil, err
+			}
+			if !active {
+				rdb.SRem(ctx, userFamilyPrefix+userID, id)
+			}
+			continue
+		}
+
+		sessions = append(sessions, Session{
+			ID:         id,
+			UserAgent:  data["user_agent"],
+			IP:         data["ip"],
+			CreatedAt:  unixField(data["created_at"]),
+			LastSeenAt: unixField(data["last_seen_at"]),
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// Mencabut sesi milik pengguna
+func RevokeSession(userID, id string) error {
+	owner, err := database.RedisClient.Get(context.Background(), familyPrefix+id).Result()
+	if errors.Is(err, redis.Nil) || (err == nil && owner != userID) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return RevokeFamily(id)
+}
+
+func unixField(v string) time.Time {
+	sec, _ := strconv.ParseInt(v, 10, 64)
+	return time.Unix(sec, 0)
+}
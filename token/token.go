@@ -0,0 +1,83 @@
+package token
+
+import (
+	"time"
+
+	"jalurku/config"
+	"jalurku/database"
+	"jalurku/model"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Masa berlaku access token, diambil dari JWT_EXPIRE (default 15 menit)
+func AccessTTL() time.Duration {
+	return config.DurationWithDefault("JWT_EXPIRE", 15*time.Minute)
+}
+
+// Informasi sesi log masuk yang ikut di access token dan refresh token
+type Grant struct {
+	UserID    string `json:"user_id"`
+	SessionID string `json:"sid,omitempty"` // Sesi log masuk, lihat StartSession
+	MFA       bool   `json:"mfa,omitempty"` // Log masuk sudah melewati verifikasi dua langkah
+}
+
+// Membuat access token JWT untuk pengguna
+func NewAccessToken(user *model.User, grant Grant) (string, error) {
+	now := time.Now()
+
+	// Permission role ikut disimpan, berubah saat token diperbarui
+	perms, err := model.PermissionsForRole(database.DB, user.Role)
+	if err != nil {
+		return "", err
+	}
+
+	claims := jwt.MapClaims{}
+	claims["iss"] = Issuer()
+	claims["username"] = user.Username
+	claims["name"] = user.Name
+	claims["user_id"] = user.ID.String()
+	claims["role"] = user.Role
+	claims["perms"] = perms
+	claims["jti"] = uuid.New().String()
+	claims["sid"] = grant.SessionID
+	claims["mfa"] = grant.MFA
+	// iat dengan presisi milidetik, agar token baru setelah pencabutan tetap berlaku
+	claims["iat"] = float64(now.UnixMilli()) / 1000
+	claims["exp"] = now.Add(AccessTTL()).Unix()
+
+	return sign(claims)
+}
+
+// Masa berlaku token impersonasi, diambil dari IMPERSONATION_EXPIRE (default 15 menit)
+func ImpersonationTTL() time.Duration {
+	return config.DurationWithDefault("IMPERSONATION_EXPIRE", 15*time.Minute)
+}
+
+// Membuat access token untuk melihat API sebagai pengguna lain.
+// Token memakai sesi milik admin (impersonator), tidak ada refresh token.
+func NewImpersonationToken(user *model.User, impersonatorID, sessionID string) (string, error) {
+	now := time.Now()
+
+	perms, err := model.PermissionsForRole(database.DB, user.Role)
+	if err != nil {
+		return "", err
+	}
+
+	claims := jwt.MapClaims{}
+	claims["iss"] = Issuer()
+	claims["username"] = user.Username
+	claims["name"] = user.Name
+	claims["user_id"] = user.ID.String()
+	claims["impersonator_id"] = impersonatorID
+	claims["role"] = user.Role
+	claims["perms"] = perms
+	claims["jti"] = uuid.New().String()
+	claims["sid"] = sessionID
+	claims["mfa"] = false
+	claims["iat"] = float64(now.UnixMilli()) / 1000
+	claims["exp"] = now.Add(ImpersonationTTL()).Unix()
+
+	return sign(claims)
+}
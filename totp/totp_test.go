@@ -0,0 +1,87 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// Secret ASCII "12345678901234567890" dari RFC 6238 lampiran B, dalam base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// Vektor uji SHA-1 RFC 6238, dipotong menjadi 6 digit terakhir
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},          // 94287082
+	{1111111109, "081804"},  // 07081804
+	{1111111111, "050471"},  // 14050471
+	{1234567890, "005924"},  // 89005924
+	{2000000000, "279037"},  // 69279037
+	{20000000000, "353130"}, // 65353130
+}
+
+func TestCodeRFC6238(t *testing.T) {
+	key := []byte("12345678901234567890")
+	for _, v := range rfcVectors {
+		if got := code(key, uint64(v.unix)/30); got != v.code {
+			t.Errorf("code at %d = %s, want %s", v.unix, got, v.code)
+		}
+	}
+}
+
+func TestValidateRFC6238(t *testing.T) {
+	for _, v := range rfcVectors {
+		counter, ok := Validate(rfcSecret, v.code, time.Unix(v.unix, 0))
+		if !ok {
+			t.Errorf("code %s rejected at %d", v.code, v.unix)
+			continue
+		}
+		if want := uint64(v.unix) / 30; counter != want {
+			t.Errorf("counter at %d = %d, want %d", v.unix, counter, want)
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	// 1234567890 ada di periode 41152263, kode 005924
+	at := time.Unix(1234567890, 0)
+
+	tests := []struct {
+		name   string
+		offset time.Duration
+		valid  bool
+	}{
+		{"same step", 0, true},
+		{"one step behind", -Period, true},
+		{"one step ahead", Period, true},
+		{"two steps behind", -2 * Period, false},
+		{"two steps ahead", 2 * Period, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter, ok := Validate(rfcSecret, "005924", at.Add(tt.offset))
+			if ok != tt.valid {
+				t.Fatalf("valid = %v, want %v", ok, tt.valid)
+			}
+			if ok && counter != 41152263 {
+				t.Fatalf("counter = %d, want 41152263", counter)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsMalformedInput(t *testing.T) {
+	at := time.Unix(59, 0)
+	for _, input := range []string{"", "28708", "2870820", "abcdef"} {
+		if _, ok := Validate(rfcSecret, input, at); ok {
+			t.Errorf("input %q accepted", input)
+		}
+	}
+	if _, ok := Validate("not base32!", "287082", at); ok {
+		t.Error("invalid secret accepted")
+	}
+	if _, ok := Validate(rfcSecret, " 287 082 ", at); !ok {
+		t.Error("code with spaces rejected")
+	}
+}